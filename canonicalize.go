@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+// CanonicalizeConfiguration rewrites the container limits and requests to
+// their canonical form, like `500m` instead of `0.5`, or `1Gi` instead of
+// `1024Mi`.
+type CanonicalizeConfiguration struct {
+	// PreferredUnits defines the unit used to write the quantities of the
+	// given resources, for example `Mi` for the memory. The quantities which
+	// are not a whole number of the preferred unit use the canonical form.
+	PreferredUnits map[string]string `json:"preferredUnits,omitempty"`
+}
+
+// preferredUnitQuantity returns the quantity of one preferred unit.
+func preferredUnitQuantity(unit string) (resource.Quantity, error) {
+	quantity, err := resource.ParseQuantity("1" + unit)
+	if err != nil || (unit != "" && quantity.Format == resource.DecimalExponent) {
+		return resource.Quantity{}, fmt.Errorf("invalid unit '%s'", unit)
+	}
+	return quantity, nil
+}
+
+func (c *CanonicalizeConfiguration) valid() error {
+	for _, resourceName := range sortedResourceNames(c.PreferredUnits) {
+		if _, err := preferredUnitQuantity(c.PreferredUnits[resourceName]); err != nil {
+			return fmt.Errorf("invalid %s preferred unit: %w", resourceName, err)
+		}
+	}
+	return nil
+}
+
+// canonicalQuantity returns the canonical form of the quantity of the given
+// resource.
+func (c *CanonicalizeConfiguration) canonicalQuantity(resourceName string, quantity resource.Quantity) string {
+	unit, found := c.PreferredUnits[resourceName]
+	if !found {
+		return quantity.String()
+	}
+	// The unit has been validated with the settings
+	unitQuantity, err := preferredUnitQuantity(unit)
+	if err != nil || !isMultipleOfStep(quantity, unitQuantity) {
+		return quantity.String()
+	}
+	units, _ := quantity.Div(unitQuantity)
+	return strconv.FormatInt(units.Value(), 10) + unit
+}
+
+// canonicalizeResources rewrites the limits and requests of the given
+// resources to their canonical form. Returns true when at least one value has
+// been changed.
+func canonicalizeResources(resources *corev1.ResourceRequirements, canonicalize *CanonicalizeConfiguration) (bool, error) {
+	if canonicalize == nil || resources == nil {
+		return false, nil
+	}
+	mutated := false
+	resourceQuantities := []struct {
+		resourceType string
+		quantities   map[string]*api_resource.Quantity
+	}{
+		{"limit", resources.Limits},
+		{"request", resources.Requests},
+	}
+	for _, resourceQuantity := range resourceQuantities {
+		for _, resourceName := range sortedResourceNames(resourceQuantity.quantities) {
+			if missingResourceQuantity(resourceQuantity.quantities, resourceName) {
+				continue
+			}
+			quantity, err := parseResourceQuantity(resourceQuantity.quantities, resourceName, resourceQuantity.resourceType)
+			if err != nil {
+				return false, err
+			}
+			canonicalQuantity := api_resource.Quantity(canonicalize.canonicalQuantity(resourceName, quantity))
+			if canonicalQuantity == *resourceQuantity.quantities[resourceName] {
+				continue
+			}
+			resourceQuantity.quantities[resourceName] = &canonicalQuantity
+			mutated = true
+		}
+	}
+	return mutated, nil
+}
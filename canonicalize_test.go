@@ -0,0 +1,156 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	apimachinery_pkg_api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+func TestCanonicalQuantity(t *testing.T) {
+	tests := []struct {
+		resourceName   string
+		quantity       string
+		preferredUnits map[string]string
+		expected       string
+	}{
+		{"cpu", "0.5", nil, "500m"},
+		{"cpu", "500000u", nil, "500m"},
+		{"cpu", "500m", nil, "500m"},
+		{"memory", "1024Mi", nil, "1Gi"},
+		{"memory", "1073741824", nil, "1073741824"},
+		{"memory", "1073741824", map[string]string{"memory": "Mi"}, "1024Mi"},
+		{"memory", "1Gi", map[string]string{"memory": "Mi"}, "1024Mi"},
+		{"memory", "100", map[string]string{"memory": "Mi"}, "100"},
+		{"cpu", "2", map[string]string{"cpu": "m"}, "2000m"},
+		{"cpu", "0.5", map[string]string{"memory": "Mi"}, "500m"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.resourceName+" "+test.quantity, func(t *testing.T) {
+			canonicalize := CanonicalizeConfiguration{PreferredUnits: test.preferredUnits}
+			canonicalQuantity := canonicalize.canonicalQuantity(test.resourceName, resource.MustParse(test.quantity))
+			if canonicalQuantity != test.expected {
+				t.Fatalf("unexpected canonical quantity. Got: %s, expected: %s", canonicalQuantity, test.expected)
+			}
+		})
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	halfCore := apimachinery_pkg_api_resource.Quantity("0.5")
+	canonicalHalfCore := apimachinery_pkg_api_resource.Quantity("500m")
+	memory := apimachinery_pkg_api_resource.Quantity("1024Mi")
+	canonicalMemory := apimachinery_pkg_api_resource.Quantity("1Gi")
+	preferredMemory := apimachinery_pkg_api_resource.Quantity("1024Mi")
+	defaultRequest := apimachinery_pkg_api_resource.Quantity("100m")
+	empty := apimachinery_pkg_api_resource.Quantity("")
+
+	tests := []struct {
+		name              string
+		resources         *corev1.ResourceRequirements
+		settings          Settings
+		expectedResources *corev1.ResourceRequirements
+		shouldMutate      bool
+		expectedErrorMsg  string
+	}{
+		{
+			"values rewritten",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &memory},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			Settings{Canonicalize: &CanonicalizeConfiguration{}},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &canonicalMemory},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &canonicalHalfCore},
+			},
+			true, "",
+		},
+		{
+			"canonical values not mutated",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &canonicalMemory},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &canonicalHalfCore},
+			},
+			Settings{Canonicalize: &CanonicalizeConfiguration{}},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &canonicalMemory},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &canonicalHalfCore},
+			},
+			false, "",
+		},
+		{
+			"null and empty quantities skipped",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &memory, "cpu": nil},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &empty},
+			},
+			Settings{Canonicalize: &CanonicalizeConfiguration{}},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &canonicalMemory, "cpu": nil},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &empty},
+			},
+			true, "",
+		},
+		{
+			"preferred unit",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &canonicalMemory},
+			},
+			Settings{Canonicalize: &CanonicalizeConfiguration{PreferredUnits: map[string]string{"memory": "Mi"}}},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &preferredMemory},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{},
+			},
+			true, "",
+		},
+		{
+			"values rewritten after injecting the defaults",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultRequest: quantityPtr("100m"),
+				},
+				Canonicalize: &CanonicalizeConfiguration{},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &canonicalHalfCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultRequest},
+			},
+			true, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest", Resources: test.resources}},
+			}
+			mutated, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedResources, podSpec.Containers[0].Resources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
@@ -433,3 +433,21 @@
   [ "$status" -eq 0 ]
   [ $(expr "$output" : '.*allowed":true') -ne 0 ]
 }
+
+@test "accept: ephemeral containers are not validated by default" {
+  run kwctl run annotated-policy.wasm -r test_data/pod_ephemeral_containers_subresource.json \
+    --settings-json '{"memory": {"ignoreValues": true}}'
+
+  [ "$status" -eq 0 ]
+  [ $(expr "$output" : '.*allowed":true') -ne 0 ]
+  [ $(expr "$output" : '.*patch.*') -eq 0 ]
+}
+
+@test "reject: ephemeral container exceeding the memory max limit" {
+  run kwctl run annotated-policy.wasm -r test_data/pod_ephemeral_containers_subresource_with_resources.json \
+    --settings-json '{"memory": {"ignoreValues": true}, "ephemeralContainers": {"memory": {"maxLimit": "512Mi"}}}'
+
+  [ "$status" -eq 0 ]
+  [ $(expr "$output" : '.*allowed":false') -ne 0 ]
+  [ $(expr "$output" : '.*invalid ephemeral container.*') -ne 0 ]
+}
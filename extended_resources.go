@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+)
+
+// isExtendedResourceName returns true when the given resource name is a
+// fully qualified extended resource name, for example `nvidia.com/gpu`.
+func isExtendedResourceName(resourceName string) bool {
+	return strings.Contains(resourceName, "/")
+}
+
+// isIntegerQuantity returns true when the quantity has no fractional part.
+func isIntegerQuantity(quantity resource.Quantity) bool {
+	return quantity.MilliValue()%1000 == 0
+}
+
+// isUnsetOrIntegerQuantity returns true when the quantity is not configured,
+// or when it has no fractional part.
+func isUnsetOrIntegerQuantity(quantity *resource.Quantity) bool {
+	return quantity == nil || isIntegerQuantity(*quantity)
+}
+
+func (s *Settings) validExtendedResources() error {
+	for _, resourceName := range sortedResourceNames(s.ExtendedResources) {
+		if !isExtendedResourceName(resourceName) || !isValidResourceName(resourceName) {
+			return fmt.Errorf("invalid extended resource name '%s': the name must be fully qualified, for example 'nvidia.com/gpu'", resourceName)
+		}
+		configuration := s.ExtendedResources[resourceName]
+		if err := configuration.validNonOvercommittable(); err != nil {
+			return errors.Join(fmt.Errorf("invalid %s settings", resourceName), err)
+		}
+		quantities := []struct {
+			name     string
+			quantity *resource.Quantity
+		}{
+			{"min limit", configuration.MinLimit},
+			{"max limit", configuration.MaxLimit},
+			{"min request", configuration.MinRequest},
+			{"max request", configuration.MaxRequest},
+		}
+		for _, quantity := range quantities {
+			if !isUnsetOrIntegerQuantity(quantity.quantity) {
+				return fmt.Errorf("invalid %s settings: %s: %s must be an integer", resourceName, quantity.name, quantity.quantity.String())
+			}
+		}
+	}
+	if s.RequireLimitsWithExtendedResources && len(s.ExtendedResources) == 0 {
+		return errors.New("requireLimitsWithExtendedResources requires the extendedResources settings")
+	}
+	return nil
+}
+
+// validateContainerExtendedResources validates the configured extended
+// resources used by the container. Kubernetes cannot overcommit the extended
+// resources, therefore their quantities must be integers and their requests
+// must be equal to their limits. The max limit of an extended resource is
+// the max number of devices a container can use.
+//
+// When RequireLimitsWithExtendedResources is true, the containers using an
+// extended resource must have a cpu and a memory limit too.
+func validateContainerExtendedResources(container *corev1.Container, settings *Settings) error {
+	if len(settings.ExtendedResources) == 0 || container.Resources == nil {
+		return nil
+	}
+	for _, resourceName := range sortedResourceNames(settings.ExtendedResources) {
+		if missingResourceQuantity(container.Resources.Limits, resourceName) && missingResourceQuantity(container.Resources.Requests, resourceName) {
+			continue
+		}
+		if err := validateNonOvercommittableResource(container, resourceName, settings.ExtendedResources[resourceName]); err != nil {
+			return err
+		}
+		// The request is equal to the limit, checking the limit is enough
+		limit, err := parseResourceQuantity(container.Resources.Limits, resourceName, "limit")
+		if err != nil {
+			return err
+		}
+		if !isIntegerQuantity(limit) {
+			return fmt.Errorf("%s limit '%s' must be an integer", resourceName, limit.String())
+		}
+		if settings.RequireLimitsWithExtendedResources {
+			if missingResourceQuantity(container.Resources.Limits, "cpu") {
+				return fmt.Errorf("container using %s does not have a cpu limit", resourceName)
+			}
+			if missingResourceQuantity(container.Resources.Limits, "memory") {
+				return fmt.Errorf("container using %s does not have a memory limit", resourceName)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	apimachinery_pkg_api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+func TestExtendedResources(t *testing.T) {
+	oneGpu := apimachinery_pkg_api_resource.Quantity("1")
+	twoGpus := apimachinery_pkg_api_resource.Quantity("2")
+	fourGpus := apimachinery_pkg_api_resource.Quantity("4")
+	halfGpu := apimachinery_pkg_api_resource.Quantity("500m")
+	oneGi := apimachinery_pkg_api_resource.Quantity("1Gi")
+	settings := Settings{
+		ExtendedResources: map[string]*ResourceConfiguration{
+			"nvidia.com/gpu": {
+				MaxLimit: quantityPtr("2"),
+			},
+		},
+	}
+	settingsRequiringLimits := Settings{
+		ExtendedResources:                  settings.ExtendedResources,
+		RequireLimitsWithExtendedResources: true,
+	}
+
+	tests := []struct {
+		name             string
+		container        corev1.Container
+		settings         Settings
+		expectedErrorMsg string
+	}{
+		{
+			"gpu request equal to the limit",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"nvidia.com/gpu": &twoGpus,
+					},
+					Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"nvidia.com/gpu": &twoGpus,
+					},
+				},
+			},
+			settings, "",
+		},
+		{
+			"gpu limit exceeding the max device count",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"nvidia.com/gpu": &fourGpus,
+					},
+				},
+			},
+			settings, "nvidia.com/gpu limit '4' exceeds the max allowed value '2'",
+		},
+		{
+			"gpu request different from the limit",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"nvidia.com/gpu": &twoGpus,
+					},
+					Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"nvidia.com/gpu": &oneGpu,
+					},
+				},
+			},
+			settings, "nvidia.com/gpu request '1' is not equal to the limit '2'",
+		},
+		{
+			"gpu request without a limit",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"nvidia.com/gpu": &oneGpu,
+					},
+				},
+			},
+			settings, "container does not have a nvidia.com/gpu limit",
+		},
+		{
+			"fractional gpu",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"nvidia.com/gpu": &halfGpu,
+					},
+				},
+			},
+			settings, "nvidia.com/gpu limit '500m' must be an integer",
+		},
+		{
+			"gpu without cpu and memory limits",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"nvidia.com/gpu": &oneGpu,
+						"memory":         &oneGi,
+					},
+				},
+			},
+			settingsRequiringLimits, "container using nvidia.com/gpu does not have a cpu limit",
+		},
+		{
+			"gpu with injected cpu and memory limits",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"nvidia.com/gpu": &oneGpu,
+					},
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultLimit: quantityPtr("1"),
+				},
+				Memory: &ResourceConfiguration{
+					DefaultLimit: quantityPtr("1Gi"),
+				},
+				ExtendedResources:                  settings.ExtendedResources,
+				RequireLimitsWithExtendedResources: true,
+			},
+			"",
+		},
+		{
+			"containers not using gpus are not required to have limits",
+			corev1.Container{
+				Image:     "image:latest",
+				Resources: &corev1.ResourceRequirements{},
+			},
+			settingsRequiringLimits, "",
+		},
+		{
+			"extended resources not configured are not validated",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"amd.com/gpu": &halfGpu,
+					},
+				},
+			},
+			settings, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{&test.container},
+			}
+			_, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+		})
+	}
+}
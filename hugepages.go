@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+)
+
+// hugepagesResourcePrefix is the prefix of the hugepages resource names. The
+// prefix is followed by the page size, for example `hugepages-2Mi`.
+const hugepagesResourcePrefix = "hugepages-"
+
+func isHugepagesResourceName(resourceName string) bool {
+	return strings.HasPrefix(resourceName, hugepagesResourcePrefix)
+}
+
+// hugepagesPageSize parses the page size of the given hugepages resource name.
+func hugepagesPageSize(resourceName string) (resource.Quantity, error) {
+	pageSize, err := resource.ParseQuantity(strings.TrimPrefix(resourceName, hugepagesResourcePrefix))
+	if err != nil {
+		return resource.Quantity{}, errors.Join(fmt.Errorf("invalid hugepages resource name '%s'", resourceName), err)
+	}
+	if pageSize.Sign() <= 0 {
+		return resource.Quantity{}, fmt.Errorf("invalid hugepages resource name '%s': the page size must be greater than zero", resourceName)
+	}
+	return pageSize, nil
+}
+
+// hugepagesConfiguration returns the configuration of the given page size.
+// The page sizes are compared by value, therefore `2Mi` and `2048Ki` share the
+// same configuration. Returns nil when the page size is not configured.
+func (s *Settings) hugepagesConfiguration(pageSize resource.Quantity) *ResourceConfiguration {
+	for configuredPageSize, configuration := range s.Hugepages {
+		parsedPageSize, err := resource.ParseQuantity(configuredPageSize)
+		if err == nil && parsedPageSize.Cmp(pageSize) == 0 {
+			return configuration
+		}
+	}
+	return nil
+}
+
+func (s *Settings) validHugepages() error {
+	parsedPageSizes := make(map[string]string)
+	for _, pageSize := range sortedResourceNames(s.Hugepages) {
+		parsedPageSize, err := hugepagesPageSize(hugepagesResourcePrefix + pageSize)
+		if err != nil {
+			return errors.Join(errors.New("invalid hugepages settings"), err)
+		}
+		if duplicated, found := parsedPageSizes[parsedPageSize.String()]; found {
+			return fmt.Errorf("invalid hugepages settings: page sizes '%s' and '%s' are the same", duplicated, pageSize)
+		}
+		parsedPageSizes[parsedPageSize.String()] = pageSize
+
+		if err := s.Hugepages[pageSize].validNonOvercommittable(); err != nil {
+			return errors.Join(fmt.Errorf("invalid hugepages %s settings", pageSize), err)
+		}
+	}
+	return nil
+}
+
+// validateContainerHugepages validates the hugepages resources of the
+// container. Kubernetes requires that:
+//
+//   - the hugepages request is equal to the limit. A request without a limit
+//     is not allowed, while a missing request defaults to the limit.
+//   - the container using hugepages requests cpu or memory too. A cpu or memory
+//     limit is accepted as well, because the missing request defaults to it.
+//
+// The hugepages quantities must also fall within the range configured for
+// their page size, when it is configured. The page sizes not configured are
+// allowed.
+func validateContainerHugepages(container *corev1.Container, settings *Settings) error {
+	if len(settings.Hugepages) == 0 || container.Resources == nil {
+		return nil
+	}
+	resourceNames := make(map[string]bool)
+	for resourceName := range container.Resources.Limits {
+		resourceNames[resourceName] = true
+	}
+	for resourceName := range container.Resources.Requests {
+		resourceNames[resourceName] = true
+	}
+
+	for _, resourceName := range sortedResourceNames(resourceNames) {
+		if !isHugepagesResourceName(resourceName) {
+			continue
+		}
+		pageSize, err := hugepagesPageSize(resourceName)
+		if err != nil {
+			return err
+		}
+		if missingResourceQuantity(container.Resources.Requests, "cpu") && missingResourceQuantity(container.Resources.Requests, "memory") &&
+			missingResourceQuantity(container.Resources.Limits, "cpu") && missingResourceQuantity(container.Resources.Limits, "memory") {
+			return fmt.Errorf("container using %s must request cpu or memory", resourceName)
+		}
+		if err := validateNonOvercommittableResource(container, resourceName, settings.hugepagesConfiguration(pageSize)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
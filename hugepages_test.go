@@ -0,0 +1,185 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	apimachinery_pkg_api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+func TestHugepages(t *testing.T) {
+	oneGi := apimachinery_pkg_api_resource.Quantity("1Gi")
+	twoGi := apimachinery_pkg_api_resource.Quantity("2Gi")
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	settings := Settings{
+		Cpu: &ResourceConfiguration{
+			MaxLimit: quantityPtr("2"),
+		},
+		Hugepages: map[string]*ResourceConfiguration{
+			"2Mi": {
+				MaxLimit: quantityPtr("1Gi"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		container        corev1.Container
+		settings         Settings
+		expectedErrorMsg string
+	}{
+		{
+			"hugepages request equal to the limit",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-2Mi": &oneGi,
+						"memory":        &oneGi,
+					},
+					Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-2Mi": &oneGi,
+					},
+				},
+			},
+			settings, "",
+		},
+		{
+			"hugepages request defaulting to the limit",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-1Gi": &twoGi,
+						"cpu":           &oneCore,
+					},
+				},
+			},
+			settings, "",
+		},
+		{
+			"hugepages page size matched by value",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-2048Ki": &twoGi,
+						"cpu":              &oneCore,
+					},
+				},
+			},
+			settings, "hugepages-2048Ki limit '2Gi' exceeds the max allowed value '1Gi'",
+		},
+		{
+			"hugepages request different from the limit",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-2Mi": &twoGi,
+						"cpu":           &oneCore,
+					},
+					Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-2Mi": &oneGi,
+					},
+				},
+			},
+			settings, "hugepages-2Mi request '1Gi' is not equal to the limit '2Gi'",
+		},
+		{
+			"hugepages request without a limit",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"cpu": &oneCore,
+					},
+					Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-2Mi": &oneGi,
+					},
+				},
+			},
+			settings, "container does not have a hugepages-2Mi limit",
+		},
+		{
+			"hugepages without cpu or memory",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-2Mi": &oneGi,
+					},
+				},
+			},
+			settings, "container using hugepages-2Mi must request cpu or memory",
+		},
+		{
+			"hugepages with an injected cpu request",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-2Mi": &oneGi,
+					},
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultRequest: quantityPtr("1"),
+				},
+				Hugepages: settings.Hugepages,
+			},
+			"",
+		},
+		{
+			"invalid hugepages page size",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-2Mb": &oneGi,
+						"cpu":           &oneCore,
+					},
+				},
+			},
+			settings, "invalid hugepages resource name 'hugepages-2Mb'",
+		},
+		{
+			"hugepages invariants not enforced without hugepages settings",
+			corev1.Container{
+				Image: "image:latest",
+				Resources: &corev1.ResourceRequirements{
+					Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-2Mi": &twoGi,
+					},
+					Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+						"hugepages-2Mi": &oneGi,
+					},
+				},
+			},
+			Settings{Cpu: settings.Cpu}, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{&test.container},
+			}
+			_, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+		})
+	}
+}
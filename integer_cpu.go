@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+// IntegerCpuConfiguration requires integer CPU quantities, which the kubelet
+// static CPU manager needs to pin exclusive cores to the Guaranteed Pods.
+type IntegerCpuConfiguration struct {
+	// RoundUpDefaults rounds the injected cpu default values up to whole
+	// cores.
+	RoundUpDefaults bool `json:"roundUpDefaults,omitempty"`
+	// NodeSelector and PodLabels select the Pods requiring integer CPU
+	// quantities. A Pod is selected when its nodeSelector includes all the
+	// NodeSelector entries, or when its labels include all the PodLabels
+	// entries. All the Pods are selected when both are empty.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	PodLabels    map[string]string `json:"podLabels,omitempty"`
+}
+
+func includesAll(values map[string]string, expected map[string]string) bool {
+	for key, value := range expected {
+		if actual, found := values[key]; !found || actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+// selects returns true when the Pod with the given spec and labels requires
+// integer CPU quantities.
+func (c *IntegerCpuConfiguration) selects(pod *corev1.PodSpec, podLabels map[string]string) bool {
+	if len(c.NodeSelector) == 0 && len(c.PodLabels) == 0 {
+		return true
+	}
+	return (len(c.NodeSelector) > 0 && includesAll(pod.NodeSelector, c.NodeSelector)) ||
+		(len(c.PodLabels) > 0 && includesAll(podLabels, c.PodLabels))
+}
+
+// roundUpToCores rounds the quantity, when defined, up to whole cores.
+func roundUpToCores(quantity *resource.Quantity) *resource.Quantity {
+	if quantity == nil || isIntegerQuantity(*quantity) {
+		return quantity
+	}
+	milliValue := quantity.MilliValue()
+	cores := milliValue / 1000
+	if milliValue > 0 {
+		cores++
+	}
+	return resource.NewQuantity(cores, resource.DecimalSI)
+}
+
+// withRoundedUpCpuDefaults returns a copy of the settings where the cpu
+// default values, including the ones of the sections and the ones derived
+// from a ratio, are rounded up to whole cores. The ephemeralContainers
+// section is skipped, because it cannot define default values.
+func (s *Settings) withRoundedUpCpuDefaults() *Settings {
+	if s == nil {
+		return nil
+	}
+	settings := *s
+	if cpu := s.resourceConfiguration("cpu"); cpu != nil {
+		configuration := *cpu
+		configuration.DefaultRequest = roundUpToCores(cpu.DefaultRequest)
+		configuration.DefaultLimit = roundUpToCores(cpu.DefaultLimit)
+		configuration.roundUpDerivedToCores = true
+		if s.Cpu != nil {
+			settings.Cpu = &configuration
+		} else {
+			settings.Resources = make(map[string]*ResourceConfiguration)
+			for resourceName, resourceConfiguration := range s.Resources {
+				settings.Resources[resourceName] = resourceConfiguration
+			}
+			settings.Resources["cpu"] = &configuration
+		}
+	}
+	settings.InitContainers = s.InitContainers.withRoundedUpCpuDefaults()
+	settings.Sidecar = s.Sidecar.withRoundedUpCpuDefaults()
+	settings.Pod = s.Pod.withRoundedUpCpuDefaults()
+	return &settings
+}
+
+func (s *Settings) validIntegerCpu() error {
+	if s.IntegerCpu == nil {
+		return nil
+	}
+	settings := s
+	if s.IntegerCpu.RoundUpDefaults {
+		settings = s.withRoundedUpCpuDefaults()
+	}
+	sections := []struct {
+		name     string
+		settings *Settings
+	}{
+		{"cpu", settings},
+		{"initContainers cpu", settings.InitContainers},
+		{"sidecar cpu", settings.Sidecar},
+		{"pod cpu", settings.Pod},
+	}
+	for _, section := range sections {
+		if section.settings == nil {
+			continue
+		}
+		configuration := section.settings.resourceConfiguration("cpu")
+		if configuration == nil {
+			continue
+		}
+		if !isUnsetOrIntegerQuantity(configuration.DefaultRequest) || !isUnsetOrIntegerQuantity(configuration.DefaultLimit) {
+			return fmt.Errorf("invalid %s settings: the default values must be whole cores when integerCpu is defined, unless roundUpDefaults is enabled", section.name)
+		}
+		if err := configuration.valid(); err != nil && !errors.Is(err, AllValuesAreZeroError{}) {
+			return errors.Join(fmt.Errorf("invalid %s settings: the default values rounded up to whole cores are not valid", section.name), err)
+		}
+	}
+	return nil
+}
+
+// validateIntegerCpuQuantities validates that the cpu request and limit of
+// the given resources, when defined, are whole cores. The name identifies
+// the resources in the error messages.
+func validateIntegerCpuQuantities(resources *corev1.ResourceRequirements, name string) error {
+	if resources == nil {
+		return nil
+	}
+	resourceQuantities := []struct {
+		resourceType string
+		quantities   map[string]*api_resource.Quantity
+	}{
+		{"request", resources.Requests},
+		{"limit", resources.Limits},
+	}
+	for _, resourceQuantity := range resourceQuantities {
+		if missingResourceQuantity(resourceQuantity.quantities, "cpu") {
+			continue
+		}
+		quantity, err := parseResourceQuantity(resourceQuantity.quantities, "cpu", resourceQuantity.resourceType)
+		if err != nil {
+			return err
+		}
+		if !isIntegerQuantity(quantity) {
+			return fmt.Errorf("%s cpu %s '%s' is not a whole number of cores, which is required by the static CPU manager", name, resourceQuantity.resourceType, quantity.String())
+		}
+	}
+	return nil
+}
+
+// validateIntegerCpu validates that the pod-level resources and the
+// containers of the Pod, excluding the ignored ones, use whole cores.
+func validateIntegerCpu(pod *corev1.PodSpec, podResources *corev1.ResourceRequirements, settings *Settings) error {
+	if settings.IntegerCpu == nil {
+		return nil
+	}
+	if err := validateIntegerCpuQuantities(podResources, "the pod-level resources"); err != nil {
+		return err
+	}
+	containers := append(append([]*corev1.Container{}, pod.Containers...), pod.InitContainers...)
+	for _, container := range containers {
+		if shouldSkipContainer(container.Image, settings.IgnoreImages) {
+			continue
+		}
+		if err := validateIntegerCpuQuantities(container.Resources, fmt.Sprintf("container '%s'", containerName(container))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	apimachinery_pkg_api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+func TestIntegerCpuSelection(t *testing.T) {
+	tests := []struct {
+		name          string
+		configuration IntegerCpuConfiguration
+		nodeSelector  map[string]string
+		podLabels     map[string]string
+		selected      bool
+	}{
+		{"all pods", IntegerCpuConfiguration{}, nil, nil, true},
+		{
+			"node selector matching",
+			IntegerCpuConfiguration{NodeSelector: map[string]string{"cpu-manager": "static"}},
+			map[string]string{"cpu-manager": "static", "zone": "a"}, nil, true,
+		},
+		{
+			"node selector not matching",
+			IntegerCpuConfiguration{NodeSelector: map[string]string{"cpu-manager": "static"}},
+			map[string]string{"cpu-manager": "none"}, nil, false,
+		},
+		{
+			"pod labels matching",
+			IntegerCpuConfiguration{NodeSelector: map[string]string{"cpu-manager": "static"}, PodLabels: map[string]string{"latency": "critical"}},
+			nil, map[string]string{"latency": "critical"}, true,
+		},
+		{
+			"pod labels not matching",
+			IntegerCpuConfiguration{PodLabels: map[string]string{"latency": "critical"}},
+			nil, map[string]string{"app": "web"}, false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := corev1.PodSpec{NodeSelector: test.nodeSelector}
+			if selected := test.configuration.selects(&pod, test.podLabels); selected != test.selected {
+				t.Fatalf("invalid selection. Expected %t, got %t", test.selected, selected)
+			}
+		})
+	}
+}
+
+func TestExtractPodLabels(t *testing.T) {
+	tests := []struct {
+		name           string
+		kind           string
+		object         string
+		expectedLabels map[string]string
+	}{
+		{"pod", "Pod", `{"metadata": {"labels": {"app": "web"}}, "spec": {}}`, map[string]string{"app": "web"}},
+		{"deployment", "Deployment", `{"metadata": {"labels": {"app": "other"}}, "spec": {"template": {"metadata": {"labels": {"app": "web"}}, "spec": {}}}}`, map[string]string{"app": "web"}},
+		{"cronjob", "CronJob", `{"spec": {"jobTemplate": {"spec": {"template": {"metadata": {"labels": {"app": "web"}}}}}}}`, map[string]string{"app": "web"}},
+		{"without labels", "Pod", `{"metadata": {}, "spec": {}}`, nil},
+		{"unsupported kind", "Service", `{"metadata": {"labels": {"app": "web"}}}`, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validationRequest := kubewarden_protocol.ValidationRequest{
+				Request: kubewarden_protocol.KubernetesAdmissionRequest{
+					Kind:   kubewarden_protocol.GroupVersionKind{Kind: test.kind},
+					Object: []byte(test.object),
+				},
+			}
+			labels, err := extractPodLabels(&validationRequest)
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if diff := cmp.Diff(test.expectedLabels, labels); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestIntegerCpu(t *testing.T) {
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	twoCores := apimachinery_pkg_api_resource.Quantity("2")
+	halfCore := apimachinery_pkg_api_resource.Quantity("500m")
+	appName := "app"
+
+	tests := []struct {
+		name              string
+		resources         *corev1.ResourceRequirements
+		settings          Settings
+		expectedResources *corev1.ResourceRequirements
+		expectedErrorMsg  string
+	}{
+		{
+			"whole cores",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			Settings{IntegerCpu: &IntegerCpuConfiguration{}},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			"",
+		},
+		{
+			"fractional request",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			Settings{IntegerCpu: &IntegerCpuConfiguration{}},
+			nil, "container 'app' cpu request '500m' is not a whole number of cores, which is required by the static CPU manager",
+		},
+		{
+			"defaults rounded up",
+			nil,
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultRequest: quantityPtr("500m"),
+					DefaultLimit:   quantityPtr("1500m"),
+				},
+				IntegerCpu: &IntegerCpuConfiguration{RoundUpDefaults: true},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			"",
+		},
+		{
+			"defaults derived from a ratio rounded up",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultLimitFromRequestRatio: quantityPtr("1.5"),
+					MaxLimit:                     quantityPtr("8"),
+				},
+				IntegerCpu: &IntegerCpuConfiguration{RoundUpDefaults: true},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			"",
+		},
+		{
+			"fractional defaults",
+			nil,
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultRequest: quantityPtr("500m"),
+				},
+				IntegerCpu: &IntegerCpuConfiguration{},
+			},
+			nil, "container 'app' cpu request '500m' is not a whole number of cores",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Name: &appName, Image: "image:latest", Resources: test.resources}},
+			}
+			_, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if diff := cmp.Diff(test.expectedResources, podSpec.Containers[0].Resources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestRoundedUpCpuDefaults(t *testing.T) {
+	section := func() *Settings {
+		return &Settings{Cpu: &ResourceConfiguration{DefaultRequest: quantityPtr("500m"), MaxLimit: quantityPtr("2")}}
+	}
+	settings := Settings{
+		Cpu:            &ResourceConfiguration{DefaultLimit: quantityPtr("1500m")},
+		InitContainers: section(),
+		Sidecar:        section(),
+		Pod:            section(),
+	}
+	rounded := settings.withRoundedUpCpuDefaults()
+
+	tests := []struct {
+		name     string
+		settings *Settings
+		original *Settings
+	}{
+		{"initContainers", rounded.InitContainers, settings.InitContainers},
+		{"sidecar", rounded.Sidecar, settings.Sidecar},
+		{"pod", rounded.Pod, settings.Pod},
+	}
+	if rounded.Cpu.DefaultLimit.String() != "2" {
+		t.Errorf("cpu default limit not rounded up: %s", rounded.Cpu.DefaultLimit.String())
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if defaultRequest := test.settings.Cpu.DefaultRequest.String(); defaultRequest != "1" {
+				t.Errorf("default request not rounded up: %s", defaultRequest)
+			}
+			if defaultRequest := test.original.Cpu.DefaultRequest.String(); defaultRequest != "500m" {
+				t.Errorf("original settings mutated: %s", defaultRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+	kubewarden "github.com/kubewarden/policy-sdk-go"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// podSpecPath returns the path of the PodSpec inside of an object of the given
+// kind. Returns nil when the kind is not supported.
+func podSpecPath(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{"spec"}
+	case "Deployment", "ReplicaSet", "StatefulSet", "DaemonSet", "ReplicationController", "Job":
+		return []string{"spec", "template", "spec"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil
+	}
+}
+
+// rawObjectField returns the field of the raw object at the given path.
+// Returns nil when the object does not define the field.
+func rawObjectField(object json.RawMessage, path []string) (json.RawMessage, error) {
+	current := object
+	for _, field := range path {
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal(current, &fields); err != nil {
+			return nil, err
+		}
+		value, found := fields[field]
+		if !found {
+			return nil, nil
+		}
+		current = value
+	}
+	return current, nil
+}
+
+// extractPodResources extracts the pod-level resources (PodSpec.resources)
+// from the object of the request. The k8s-objects PodSpec type does not
+// define this field, therefore it's read from the raw object.
+//
+// Returns nil when the object does not define the pod-level resources.
+func extractPodResources(validationRequest *kubewarden_protocol.ValidationRequest) (*corev1.ResourceRequirements, error) {
+	path := podSpecPath(validationRequest.Request.Kind.Kind)
+	if path == nil || len(validationRequest.Request.Object) == 0 {
+		return nil, nil
+	}
+	rawPodSpec, err := rawObjectField(validationRequest.Request.Object, path)
+	if err != nil || rawPodSpec == nil {
+		return nil, err
+	}
+	podSpec := struct {
+		Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	}{}
+	if err := json.Unmarshal(rawPodSpec, &podSpec); err != nil {
+		return nil, err
+	}
+	return podSpec.Resources, nil
+}
+
+// extractPodLabels extracts the labels of the Pod, or of the Pod template,
+// from the object of the request.
+func extractPodLabels(validationRequest *kubewarden_protocol.ValidationRequest) (map[string]string, error) {
+	path := podSpecPath(validationRequest.Request.Kind.Kind)
+	if path == nil || len(validationRequest.Request.Object) == 0 {
+		return nil, nil
+	}
+	// The metadata is next to the PodSpec
+	metadataPath := append(append([]string{}, path[:len(path)-1]...), "metadata")
+	rawMetadata, err := rawObjectField(validationRequest.Request.Object, metadataPath)
+	if err != nil || rawMetadata == nil {
+		return nil, err
+	}
+	metadata := struct {
+		Labels map[string]string `json:"labels,omitempty"`
+	}{}
+	if err := json.Unmarshal(rawMetadata, &metadata); err != nil {
+		return nil, err
+	}
+	return metadata.Labels, nil
+}
+
+func hasPodResources(podResources *corev1.ResourceRequirements) bool {
+	return podResources != nil && (len(podResources.Limits) > 0 || len(podResources.Requests) > 0)
+}
+
+// mutatePodSpecFromRequest mutates the object of the request to use the given
+// PodSpec, like kubewarden.MutatePodSpecFromRequest. The pod-level resources
+// are not part of the k8s-objects PodSpec type, therefore they are added back
+// to the mutated object.
+func mutatePodSpecFromRequest(validationRequest kubewarden_protocol.ValidationRequest, podSpec corev1.PodSpec, podResources *corev1.ResourceRequirements) ([]byte, error) {
+	responsePayload, err := kubewarden.MutatePodSpecFromRequest(validationRequest, podSpec)
+	if err != nil || !hasPodResources(podResources) {
+		return responsePayload, err
+	}
+
+	response := kubewarden_protocol.ValidationResponse{}
+	if err := json.Unmarshal(responsePayload, &response); err != nil {
+		return nil, err
+	}
+	object, ok := response.MutatedObject.(map[string]interface{})
+	if !ok {
+		return responsePayload, nil
+	}
+	for _, field := range podSpecPath(validationRequest.Request.Kind.Kind) {
+		object, ok = object[field].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot find the PodSpec inside of the %s object", validationRequest.Request.Kind.Kind)
+		}
+	}
+	object["resources"] = podResources
+	return json.Marshal(response)
+}
+
+// podRequestsWithDefaults returns the pod-level requests where the missing
+// ones are set to the pod-level limits, like the API server does.
+func podRequestsWithDefaults(podResources *corev1.ResourceRequirements) map[string]*api_resource.Quantity {
+	requests := make(map[string]*api_resource.Quantity)
+	for name, quantity := range podResources.Requests {
+		requests[name] = quantity
+	}
+	for name, quantity := range podResources.Limits {
+		if missingResourceQuantity(requests, name) {
+			requests[name] = quantity
+		}
+	}
+	return requests
+}
+
+// withPodResources returns a copy of the container where the missing limits
+// and requests are replaced by the pod-level ones. The pod-level limit is used
+// as the request when the pod-level request is missing. It's used to check the
+// presence of the container limits and requests, because the pod-level
+// resources can be used in place of the container ones.
+func withPodResources(container *corev1.Container, podResources *corev1.ResourceRequirements) *corev1.Container {
+	if !hasPodResources(podResources) {
+		return container
+	}
+	resources := &corev1.ResourceRequirements{
+		Limits:   make(map[string]*api_resource.Quantity),
+		Requests: make(map[string]*api_resource.Quantity),
+	}
+	for name, quantity := range podResources.Limits {
+		resources.Limits[name] = quantity
+	}
+	for name, quantity := range podRequestsWithDefaults(podResources) {
+		resources.Requests[name] = quantity
+	}
+	if container.Resources != nil {
+		for name, quantity := range container.Resources.Limits {
+			if !missingResourceQuantity(container.Resources.Limits, name) {
+				resources.Limits[name] = quantity
+			}
+		}
+		for name, quantity := range container.Resources.Requests {
+			if !missingResourceQuantity(container.Resources.Requests, name) {
+				resources.Requests[name] = quantity
+			}
+		}
+	}
+	containerCopy := *container
+	containerCopy.Resources = resources
+	return &containerCopy
+}
+
+// parseResourceList parses the given resource quantities. resourceType
+// describes the quantities in the error messages.
+func parseResourceList(quantities map[string]*api_resource.Quantity, resourceType string) (resource.List, error) {
+	list, err := resource.ParseList(quantities)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("invalid %s", resourceType), err)
+	}
+	return list, nil
+}
+
+// aggregateContainerResources computes the resources requested by all the
+// containers of the Pod, like the kube-scheduler does:
+//
+// - the app containers and the sidecar containers run at the same time, so
+// their resources are summed.
+// - the init containers run one at a time, while the sidecar containers
+// started before them are running. The Pod needs the max of these values.
+//
+// The resources of the Pod are the max between the two values above.
+// resourceType selects the container quantities to aggregate: "request" or
+// "limit".
+func aggregateContainerResources(pod *corev1.PodSpec, resourceType string) (resource.List, error) {
+	containerQuantities := func(container *corev1.Container) (resource.List, error) {
+		if container.Resources == nil {
+			return resource.List{}, nil
+		}
+		if resourceType == "limit" {
+			return parseResourceList(container.Resources.Limits, resourceType)
+		}
+		return parseResourceList(container.Resources.Requests, resourceType)
+	}
+
+	total := resource.List{}
+	for _, container := range pod.Containers {
+		quantities, err := containerQuantities(container)
+		if err != nil {
+			return nil, err
+		}
+		total = total.Add(quantities)
+	}
+
+	sidecarsTotal := resource.List{}
+	initContainersMax := resource.List{}
+	for _, container := range pod.InitContainers {
+		quantities, err := containerQuantities(container)
+		if err != nil {
+			return nil, err
+		}
+		if isSidecarContainer(container) {
+			total = total.Add(quantities)
+			sidecarsTotal = sidecarsTotal.Add(quantities)
+			initContainersMax = initContainersMax.Max(sidecarsTotal)
+		} else {
+			initContainersMax = initContainersMax.Max(sidecarsTotal.Add(quantities))
+		}
+	}
+	return total.Max(initContainersMax), nil
+}
+
+func sortedResourceNames[T any](resources map[string]T) []string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validatePodResourcesBudget checks that the containers fit in the pod-level
+// resources:
+//
+// - every container limit must be less than or equal to the pod-level limit.
+// - the aggregated container requests must be less than or equal to the
+// pod-level request. The missing pod-level request defaults to the limit.
+func validatePodResourcesBudget(pod *corev1.PodSpec, podResources *corev1.ResourceRequirements) error {
+	if !hasPodResources(podResources) {
+		return nil
+	}
+	podLimits, err := parseResourceList(podResources.Limits, "pod-level limit")
+	if err != nil {
+		return err
+	}
+	podRequests, err := parseResourceList(podRequestsWithDefaults(podResources), "pod-level request")
+	if err != nil {
+		return err
+	}
+
+	containers := append(append([]*corev1.Container{}, pod.Containers...), pod.InitContainers...)
+	for _, container := range containers {
+		if container.Resources == nil {
+			continue
+		}
+		containerLimits, err := parseResourceList(container.Resources.Limits, "limit")
+		if err != nil {
+			return err
+		}
+		if lessOrEqual, greater := containerLimits.LessOrEqual(podLimits); !lessOrEqual {
+			name := greater[0]
+			containerLimit := containerLimits[name]
+			podLimit := podLimits[name]
+			return errors.Join(errors.New("container limit exceeds the pod-level limit"),
+				fmt.Errorf("%s limit '%s' exceeds the max allowed value '%s'", name, containerLimit.String(), podLimit.String()))
+		}
+	}
+
+	containerRequests, err := aggregateContainerResources(pod, "request")
+	if err != nil {
+		return err
+	}
+	if lessOrEqual, greater := containerRequests.LessOrEqual(podRequests); !lessOrEqual {
+		name := greater[0]
+		containersRequest := containerRequests[name]
+		podRequest := podRequests[name]
+		return fmt.Errorf("the %s requested by the containers '%s' exceeds the pod-level request '%s'", name, containersRequest.String(), podRequest.String())
+	}
+	return nil
+}
+
+// validateAndAdjustPodResources validates the pod-level resources against the
+// pod settings, and mutates them when required. Returns true when the
+// pod-level resources have been mutated.
+func validateAndAdjustPodResources(podResources *corev1.ResourceRequirements, settings *Settings) (bool, error) {
+	podContainer := &corev1.Container{Resources: podResources}
+	if err := validateContainerCheckPresence(podContainer, settings); err != nil {
+		return false, err
+	}
+	return validateAndAdjustContainer(podContainer, settings)
+}
+
+// containerName returns the name of the container to be used in the error
+// messages
+func containerName(container *corev1.Container) string {
+	if container.Name == nil {
+		return ""
+	}
+	return *container.Name
+}
+
+// findContainerWithoutLimit returns the first container of the Pod that does
+// not define a limit for the given resource, or nil when all of them do.
+func findContainerWithoutLimit(pod *corev1.PodSpec, resourceName string) *corev1.Container {
+	containers := append(append([]*corev1.Container{}, pod.Containers...), pod.InitContainers...)
+	for _, container := range containers {
+		if container.Resources == nil || missingResourceQuantity(container.Resources.Limits, resourceName) {
+			return container
+		}
+	}
+	return nil
+}
+
+// effectivePodResources computes the resources of the Pod like the
+// kube-scheduler does. The pod-level resources take precedence over the
+// aggregated container resources. The Pod overhead, set by the RuntimeClass,
+// is added on top of them. Like Kubernetes does, the overhead is added only to
+// the limits which are set.
+func effectivePodResources(pod *corev1.PodSpec, podQuantities map[string]*api_resource.Quantity, resourceType string) (resource.List, error) {
+	effectiveResources, err := aggregateContainerResources(pod, resourceType)
+	if err != nil {
+		return nil, err
+	}
+	podLevelResources, err := parseResourceList(podQuantities, "pod-level "+resourceType)
+	if err != nil {
+		return nil, err
+	}
+	for name, quantity := range podLevelResources {
+		effectiveResources[name] = quantity
+	}
+	overhead, err := parseResourceList(pod.Overhead, "overhead")
+	if err != nil {
+		return nil, err
+	}
+	if resourceType == "limit" {
+		for _, name := range effectiveResources.Missing(overhead.Names()...) {
+			delete(overhead, name)
+		}
+	}
+	return effectiveResources.Add(overhead), nil
+}
+
+// validatePodEffectiveResources checks that the effective resources of the
+// Pod do not exceed the maxPodRequest and maxPodLimit settings.
+//
+// The effective limit of the Pod is unbounded when a container does not
+// define a limit and the Pod does not define the pod-level limit. In this
+// case the Pod is rejected when a maxPodLimit is configured for the resource.
+func validatePodEffectiveResources(pod *corev1.PodSpec, podResources *corev1.ResourceRequirements, settings *Settings) error {
+	if len(settings.MaxPodRequest) > 0 {
+		podRequests, err := effectivePodResources(pod, podRequestsWithDefaults(podResources), "request")
+		if err != nil {
+			return err
+		}
+		if lessOrEqual, greater := podRequests.LessOrEqual(settings.MaxPodRequest); !lessOrEqual {
+			name := greater[0]
+			podRequest := podRequests[name]
+			maxPodRequest := settings.MaxPodRequest[name]
+			return fmt.Errorf("pod %s request '%s' exceeds the max allowed value '%s'", name, podRequest.String(), maxPodRequest.String())
+		}
+	}
+
+	if len(settings.MaxPodLimit) > 0 {
+		podLimits, err := effectivePodResources(pod, podResources.Limits, "limit")
+		if err != nil {
+			return err
+		}
+		for _, name := range sortedResourceNames(settings.MaxPodLimit) {
+			maxPodLimit := settings.MaxPodLimit[name]
+			if missingResourceQuantity(podResources.Limits, name) {
+				if container := findContainerWithoutLimit(pod, name); container != nil {
+					return fmt.Errorf("pod %s limit is unbounded, because container '%s' does not have a %s limit. The max allowed value is '%s'", name, containerName(container), name, maxPodLimit.String())
+				}
+			}
+			podLimit := podLimits[name]
+			if podLimit.Cmp(maxPodLimit) > 0 {
+				return fmt.Errorf("pod %s limit '%s' exceeds the max allowed value '%s'", name, podLimit.String(), maxPodLimit.String())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,758 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	apimachinery_pkg_api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+	kubewarden_testing "github.com/kubewarden/policy-sdk-go/testing"
+)
+
+func TestExtractPodResources(t *testing.T) {
+	oneCoreCpuQuantity := apimachinery_pkg_api_resource.Quantity("1")
+
+	tests := []struct {
+		name                 string
+		kind                 string
+		object               string
+		expectedPodResources *corev1.ResourceRequirements
+	}{
+		{
+			"pod with pod-level resources",
+			"Pod",
+			`{"spec": {"resources": {"limits": {"cpu": "1"}}, "containers": []}}`,
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &oneCoreCpuQuantity,
+				},
+			},
+		},
+		{
+			"deployment with pod-level resources",
+			"Deployment",
+			`{"spec": {"template": {"spec": {"resources": {"limits": {"cpu": "1"}}, "containers": []}}}}`,
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &oneCoreCpuQuantity,
+				},
+			},
+		},
+		{
+			"cronjob with pod-level resources",
+			"CronJob",
+			`{"spec": {"jobTemplate": {"spec": {"template": {"spec": {"resources": {"limits": {"cpu": "1"}}, "containers": []}}}}}}`,
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &oneCoreCpuQuantity,
+				},
+			},
+		},
+		{
+			"pod without pod-level resources",
+			"Pod",
+			`{"spec": {"containers": []}}`,
+			nil,
+		},
+		{
+			"deployment without template",
+			"Deployment",
+			`{"spec": {}}`,
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validationRequest := &kubewarden_protocol.ValidationRequest{
+				Request: kubewarden_protocol.KubernetesAdmissionRequest{
+					Kind:   kubewarden_protocol.GroupVersionKind{Kind: test.kind},
+					Object: json.RawMessage(test.object),
+				},
+			}
+			podResources, err := extractPodResources(validationRequest)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.expectedPodResources, podResources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestAggregateContainerResources(t *testing.T) {
+	quantity := func(value string) *apimachinery_pkg_api_resource.Quantity {
+		q := apimachinery_pkg_api_resource.Quantity(value)
+		return &q
+	}
+	container := func(cpu string, restartPolicy string) *corev1.Container {
+		return &corev1.Container{
+			RestartPolicy: restartPolicy,
+			Resources: &corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": quantity(cpu),
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		podSpec     corev1.PodSpec
+		expectedCpu string
+	}{
+		{
+			"app containers are summed",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{container("1", ""), container("500m", "")},
+			},
+			"1500m",
+		},
+		{
+			"init container bigger than the app containers",
+			corev1.PodSpec{
+				Containers:     []*corev1.Container{container("1", "")},
+				InitContainers: []*corev1.Container{container("3", "")},
+			},
+			"3",
+		},
+		{
+			"sidecar containers are added to the app containers",
+			corev1.PodSpec{
+				Containers:     []*corev1.Container{container("1", "")},
+				InitContainers: []*corev1.Container{container("500m", "Always"), container("1", "")},
+			},
+			"1500m",
+		},
+		{
+			"init container running after a sidecar container",
+			corev1.PodSpec{
+				Containers:     []*corev1.Container{container("1", "")},
+				InitContainers: []*corev1.Container{container("1", "Always"), container("2", "")},
+			},
+			"3",
+		},
+		{
+			"init container running before a sidecar container",
+			corev1.PodSpec{
+				Containers:     []*corev1.Container{container("1", "")},
+				InitContainers: []*corev1.Container{container("2", ""), container("1", "Always")},
+			},
+			"2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests, err := aggregateContainerResources(&test.podSpec, "request")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cpu := requests["cpu"]
+			expectedCpu := resource.MustParse(test.expectedCpu)
+			if cpu.Cmp(expectedCpu) != 0 {
+				t.Fatalf("invalid aggregated cpu request. Got '%s', expected '%s'", cpu.String(), expectedCpu.String())
+			}
+		})
+	}
+}
+
+func TestPodLevelResources(t *testing.T) {
+	oneCore := resource.MustParse("1")
+	twoCore := resource.MustParse("2")
+	oneCoreCpuQuantity := apimachinery_pkg_api_resource.Quantity("1")
+	twoCoreCpuQuantity := apimachinery_pkg_api_resource.Quantity("2")
+	oneGiMemoryQuantity := apimachinery_pkg_api_resource.Quantity("1Gi")
+	oneAndHalfCoreCpuQuantity := apimachinery_pkg_api_resource.Quantity("1500m")
+
+	tests := []struct {
+		name                 string
+		podSpec              corev1.PodSpec
+		podResources         *corev1.ResourceRequirements
+		settings             Settings
+		expectedContainers   []*corev1.Container
+		expectedPodResources *corev1.ResourceRequirements
+		shouldMutate         bool
+		expectedErrorMsg     string
+	}{
+		{
+			"pod-level limits are accepted in place of the container limits",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"cpu": &oneCoreCpuQuantity,
+							},
+						},
+					},
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &twoCoreCpuQuantity,
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					IgnoreValues: true,
+				},
+			},
+			[]*corev1.Container{
+				{
+					Image: "image:latest",
+					Resources: &corev1.ResourceRequirements{
+						Limits: map[string]*apimachinery_pkg_api_resource.Quantity{},
+						Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"cpu": &oneCoreCpuQuantity,
+						},
+					},
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &twoCoreCpuQuantity,
+				},
+			},
+			false, "",
+		},
+		{
+			"pod-level limit is accepted in place of the missing memory requests",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest"}},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"memory": &oneGiMemoryQuantity,
+				},
+			},
+			Settings{
+				Memory: &ResourceConfiguration{
+					IgnoreValues: true,
+				},
+			},
+			[]*corev1.Container{
+				{
+					Image: "image:latest",
+					Resources: &corev1.ResourceRequirements{
+						Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{},
+						Requests: map[string]*apimachinery_pkg_api_resource.Quantity{},
+					},
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"memory": &oneGiMemoryQuantity,
+				},
+			},
+			false, "",
+		},
+		{
+			"container default limit is not injected when the pod-level limit is defined",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest"}},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &oneCoreCpuQuantity,
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultLimit:   &twoCore,
+					DefaultRequest: &oneCore,
+				},
+			},
+			[]*corev1.Container{
+				{
+					Image: "image:latest",
+					Resources: &corev1.ResourceRequirements{
+						Limits: map[string]*apimachinery_pkg_api_resource.Quantity{},
+						Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"cpu": &oneCoreCpuQuantity,
+						},
+					},
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &oneCoreCpuQuantity,
+				},
+			},
+			true, "",
+		},
+		{
+			"container limit exceeding the pod-level limit",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"cpu": &twoCoreCpuQuantity,
+							},
+						},
+					},
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &oneCoreCpuQuantity,
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxLimit: &twoCore,
+				},
+			},
+			nil, nil, false, "container limit exceeds the pod-level limit\ncpu limit '2' exceeds the max allowed value '1'",
+		},
+		{
+			"container requests exceeding the pod-level request",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"cpu": &oneCoreCpuQuantity,
+							},
+						},
+					},
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"cpu": &oneCoreCpuQuantity,
+							},
+						},
+					},
+				},
+			},
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &oneCoreCpuQuantity,
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxLimit: &twoCore,
+				},
+			},
+			nil, nil, false, "the cpu requested by the containers '2' exceeds the pod-level request '1'",
+		},
+		{
+			"container requests exceeding the pod-level request defaulted to the limit",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"cpu": &oneAndHalfCoreCpuQuantity,
+							},
+						},
+					},
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"cpu": &oneAndHalfCoreCpuQuantity,
+							},
+						},
+					},
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &twoCoreCpuQuantity,
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxLimit: &twoCore,
+				},
+			},
+			nil, nil, false, "the cpu requested by the containers '3' exceeds the pod-level request '2'",
+		},
+		{
+			"pod-level limit exceeding the pod settings",
+			corev1.PodSpec{},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &twoCoreCpuQuantity,
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxLimit: &twoCore,
+				},
+				Pod: &Settings{
+					Cpu: &ResourceConfiguration{
+						MaxLimit: &oneCore,
+					},
+				},
+			},
+			nil, nil, false, "invalid pod-level resources\ncpu limit '2' exceeds the max allowed value '1'",
+		},
+		{
+			"pod-level default limit injected",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"cpu": &oneCoreCpuQuantity,
+							},
+						},
+					},
+				},
+			},
+			nil,
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultLimit: &oneCore,
+				},
+				Pod: &Settings{
+					Cpu: &ResourceConfiguration{
+						DefaultLimit: &twoCore,
+					},
+				},
+			},
+			[]*corev1.Container{
+				{
+					Image: "image:latest",
+					Resources: &corev1.ResourceRequirements{
+						Limits: map[string]*apimachinery_pkg_api_resource.Quantity{},
+						Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"cpu": &oneCoreCpuQuantity,
+						},
+					},
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+					"cpu": &twoCoreCpuQuantity,
+				},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{},
+			},
+			true, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podResources := test.podResources
+			if podResources == nil {
+				podResources = &corev1.ResourceRequirements{}
+			}
+			mutated, err := validatePodSpec(&test.podSpec, podResources, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedContainers, test.podSpec.Containers); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+			if diff := cmp.Diff(test.expectedPodResources, podResources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestMutationKeepsPodLevelResources(t *testing.T) {
+	payload, err := kubewarden_testing.BuildValidationRequestFromFixture(
+		"test_data/pod_with_pod_level_resources.json",
+		json.RawMessage(`{"memory": {"defaultRequest": "256Mi"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	responsePayload, err := validate(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	response := struct {
+		Accepted      bool `json:"accepted"`
+		MutatedObject struct {
+			Spec struct {
+				Resources *corev1.ResourceRequirements `json:"resources"`
+			} `json:"spec"`
+		} `json:"mutated_object"`
+	}{}
+	if err := json.Unmarshal(responsePayload, &response); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !response.Accepted {
+		t.Fatalf("request should be accepted: %s", responsePayload)
+	}
+	twoCoreCpuQuantity := apimachinery_pkg_api_resource.Quantity("2")
+	twoGiMemoryQuantity := apimachinery_pkg_api_resource.Quantity("2Gi")
+	expectedPodResources := &corev1.ResourceRequirements{
+		Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+			"cpu":    &twoCoreCpuQuantity,
+			"memory": &twoGiMemoryQuantity,
+		},
+	}
+	if diff := cmp.Diff(expectedPodResources, response.MutatedObject.Spec.Resources); diff != "" {
+		t.Fatalf("pod-level resources not preserved by the mutation: %s", diff)
+	}
+}
+
+func TestPodLevelLimitsOnlyAreAccepted(t *testing.T) {
+	payload, err := kubewarden_testing.BuildValidationRequestFromFixture(
+		"test_data/pod_with_pod_level_resources.json",
+		json.RawMessage(`{"memory": {"ignoreValues": true}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	responsePayload, err := validate(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var response kubewarden_protocol.ValidationResponse
+	if err := json.Unmarshal(responsePayload, &response); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !response.Accepted {
+		t.Fatalf("request should be accepted: %s", *response.Message)
+	}
+}
+
+func TestPodEffectiveResources(t *testing.T) {
+	quantity := func(value string) *apimachinery_pkg_api_resource.Quantity {
+		q := apimachinery_pkg_api_resource.Quantity(value)
+		return &q
+	}
+	appName := "app"
+	sidecarName := "sidecar"
+
+	tests := []struct {
+		name             string
+		podSpec          corev1.PodSpec
+		podResources     *corev1.ResourceRequirements
+		settings         Settings
+		expectedErrorMsg string
+	}{
+		{
+			"pod request within the max pod request",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("1")},
+						},
+					},
+				},
+				InitContainers: []*corev1.Container{
+					{
+						Name:          &sidecarName,
+						RestartPolicy: "Always",
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("500m")},
+						},
+					},
+				},
+				Overhead: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("250m")},
+			},
+			nil,
+			Settings{
+				MaxPodRequest: map[string]resource.Quantity{"cpu": resource.MustParse("1750m")},
+			},
+			"",
+		},
+		{
+			"pod request including the overhead exceeds the max pod request",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("1")},
+						},
+					},
+				},
+				InitContainers: []*corev1.Container{
+					{
+						Name:          &sidecarName,
+						RestartPolicy: "Always",
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("500m")},
+						},
+					},
+				},
+				Overhead: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("250m")},
+			},
+			nil,
+			Settings{
+				MaxPodRequest: map[string]resource.Quantity{"cpu": resource.MustParse("1500m")},
+			},
+			"pod cpu request '1750m' exceeds the max allowed value '1500m'",
+		},
+		{
+			"pod request of a big init container exceeds the max pod request",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": quantity("1Gi")},
+						},
+					},
+				},
+				InitContainers: []*corev1.Container{
+					{
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": quantity("4Gi")},
+						},
+					},
+				},
+			},
+			nil,
+			Settings{
+				MaxPodRequest: map[string]resource.Quantity{"memory": resource.MustParse("2Gi")},
+			},
+			"pod memory request '4Gi' exceeds the max allowed value '2Gi'",
+		},
+		{
+			"pod-level request takes precedence over the container requests",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("1")},
+						},
+					},
+				},
+			},
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("3")},
+			},
+			Settings{
+				MaxPodRequest: map[string]resource.Quantity{"cpu": resource.MustParse("2")},
+			},
+			"pod cpu request '3' exceeds the max allowed value '2'",
+		},
+		{
+			"pod-level limit used as the missing pod-level request",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+					},
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("4")},
+			},
+			Settings{
+				MaxPodRequest: map[string]resource.Quantity{"cpu": resource.MustParse("1")},
+			},
+			"pod cpu request '4' exceeds the max allowed value '1'",
+		},
+		{
+			"pod limit exceeds the max pod limit",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("2")},
+						},
+					},
+					{
+						Name: &sidecarName,
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("2")},
+						},
+					},
+				},
+			},
+			nil,
+			Settings{
+				MaxPodLimit: map[string]resource.Quantity{"cpu": resource.MustParse("3")},
+			},
+			"pod cpu limit '4' exceeds the max allowed value '3'",
+		},
+		{
+			"unbounded pod limit",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("1")},
+						},
+					},
+					{
+						Name: &sidecarName,
+					},
+				},
+			},
+			nil,
+			Settings{
+				MaxPodLimit: map[string]resource.Quantity{"cpu": resource.MustParse("3")},
+			},
+			"pod cpu limit is unbounded, because container 'sidecar' does not have a cpu limit",
+		},
+		{
+			"pod-level limit bounds the containers without limits",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+					},
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": quantity("2")},
+			},
+			Settings{
+				MaxPodLimit: map[string]resource.Quantity{"cpu": resource.MustParse("3")},
+			},
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podResources := test.podResources
+			if podResources == nil {
+				podResources = &corev1.ResourceRequirements{}
+			}
+			err := validatePodEffectiveResources(&test.podSpec, podResources, &test.settings)
+			if len(test.expectedErrorMsg) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %q", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+			}
+			if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+				t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+			}
+		})
+	}
+}
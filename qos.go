@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+)
+
+const (
+	qosClassBestEffort = "BestEffort"
+	qosClassBurstable  = "Burstable"
+	qosClassGuaranteed = "Guaranteed"
+)
+
+// qosClassRanks orders the QoS classes from the lowest to the highest one.
+var qosClassRanks = map[string]int{
+	qosClassBestEffort: 0,
+	qosClassBurstable:  1,
+	qosClassGuaranteed: 2,
+}
+
+// qosResourceNames are the resources used by Kubernetes to compute the QoS
+// class of a Pod.
+var qosResourceNames = []string{"cpu", "memory"}
+
+// QosClassConfiguration defines the QoS classes allowed for the Pods, as a
+// list of classes or as the lowest allowed class.
+type QosClassConfiguration struct {
+	Allowed []string `json:"allowed,omitempty"`
+	Minimum string   `json:"minimum,omitempty"`
+}
+
+func (q *QosClassConfiguration) valid() error {
+	if len(q.Allowed) == 0 && q.Minimum == "" {
+		return errors.New("one of allowed or minimum must be defined")
+	}
+	if len(q.Allowed) > 0 && q.Minimum != "" {
+		return errors.New("allowed and minimum cannot be both defined")
+	}
+	for _, qosClass := range append(append([]string{}, q.Allowed...), q.Minimum) {
+		if _, found := qosClassRanks[qosClass]; !found && qosClass != "" {
+			return fmt.Errorf("invalid QoS class '%s'. Valid values are %s, %s and %s", qosClass, qosClassBestEffort, qosClassBurstable, qosClassGuaranteed)
+		}
+	}
+	return nil
+}
+
+func (q *QosClassConfiguration) allows(qosClass string) bool {
+	if q.Minimum != "" {
+		return qosClassRanks[qosClass] >= qosClassRanks[q.Minimum]
+	}
+	for _, allowed := range q.Allowed {
+		if allowed == qosClass {
+			return true
+		}
+	}
+	return false
+}
+
+// qosQuantities returns the limit and the request of the given resource. The
+// missing request defaults to the limit, like the API server does before the
+// QoS class is computed.
+func qosQuantities(resources *corev1.ResourceRequirements, resourceName string) (resource.Quantity, resource.Quantity, error) {
+	limit := resource.Quantity{}
+	request := resource.Quantity{}
+	if resources == nil {
+		return limit, request, nil
+	}
+	var err error
+	if !missingResourceQuantity(resources.Limits, resourceName) {
+		if limit, err = parseResourceQuantity(resources.Limits, resourceName, "limit"); err != nil {
+			return limit, request, err
+		}
+	}
+	request = limit
+	if !missingResourceQuantity(resources.Requests, resourceName) {
+		if request, err = parseResourceQuantity(resources.Requests, resourceName, "request"); err != nil {
+			return limit, request, err
+		}
+	}
+	return limit, request, nil
+}
+
+// podQOSClass computes the QoS class of the Pod, like Kubernetes does, using
+// the pod-level resources when defined, or the resources of the app and init
+// containers otherwise. When the Pod is not Guaranteed, it also returns the
+// reason, which names the first container breaking the Guaranteed class.
+func podQOSClass(pod *corev1.PodSpec, podResources *corev1.ResourceRequirements) (string, string, error) {
+	type qosResources struct {
+		name      string
+		resources *corev1.ResourceRequirements
+	}
+	entries := []qosResources{}
+	if hasPodResources(podResources) {
+		entries = append(entries, qosResources{"the pod-level resources", podResources})
+	} else {
+		containers := append(append([]*corev1.Container{}, pod.Containers...), pod.InitContainers...)
+		for _, container := range containers {
+			entries = append(entries, qosResources{fmt.Sprintf("container '%s'", containerName(container)), container.Resources})
+		}
+	}
+
+	hasQuantities := false
+	notGuaranteedReason := ""
+	for _, entry := range entries {
+		for _, resourceName := range qosResourceNames {
+			limit, request, err := qosQuantities(entry.resources, resourceName)
+			if err != nil {
+				return "", "", err
+			}
+			if limit.Sign() > 0 || request.Sign() > 0 {
+				hasQuantities = true
+			}
+			if notGuaranteedReason != "" {
+				continue
+			}
+			if limit.Sign() <= 0 {
+				notGuaranteedReason = fmt.Sprintf("%s does not have %s limit", entry.name, withIndefiniteArticle(resourceName))
+			} else if request.Cmp(limit) != 0 {
+				notGuaranteedReason = fmt.Sprintf("%s %s request '%s' is not equal to the limit '%s'", entry.name, resourceName, request.String(), limit.String())
+			}
+		}
+	}
+
+	switch {
+	case !hasQuantities:
+		return qosClassBestEffort, "none of the containers has cpu or memory requests or limits", nil
+	case notGuaranteedReason == "":
+		return qosClassGuaranteed, "", nil
+	default:
+		return qosClassBurstable, notGuaranteedReason, nil
+	}
+}
+
+// validatePodQOSClass validates the QoS class of the Pod, computed after
+// applying the default values, against the QosClass settings.
+func validatePodQOSClass(pod *corev1.PodSpec, podResources *corev1.ResourceRequirements, settings *Settings) error {
+	if settings.QosClass == nil {
+		return nil
+	}
+	qosClass, reason, err := podQOSClass(pod, podResources)
+	if err != nil {
+		return err
+	}
+	if settings.QosClass.allows(qosClass) {
+		return nil
+	}
+	qosClassErr := fmt.Errorf("the Pod QoS class '%s' is not allowed", qosClass)
+	if reason == "" {
+		return qosClassErr
+	}
+	return errors.Join(qosClassErr, errors.New(reason))
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	apimachinery_pkg_api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+func TestPodQOSClass(t *testing.T) {
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	halfCore := apimachinery_pkg_api_resource.Quantity("500m")
+	oneGi := apimachinery_pkg_api_resource.Quantity("1Gi")
+	zero := apimachinery_pkg_api_resource.Quantity("0")
+	appName := "app"
+	initName := "init"
+
+	tests := []struct {
+		name             string
+		podSpec          corev1.PodSpec
+		podResources     *corev1.ResourceRequirements
+		expectedQOSClass string
+		expectedReason   string
+	}{
+		{
+			"no resources",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{{Name: &appName}},
+			},
+			nil, qosClassBestEffort, "none of the containers has cpu or memory requests or limits",
+		},
+		{
+			"zero requests",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &zero},
+						},
+					},
+				},
+			},
+			nil, qosClassBestEffort, "none of the containers has cpu or memory requests or limits",
+		},
+		{
+			"limits only",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore, "memory": &oneGi},
+						},
+					},
+				},
+			},
+			nil, qosClassGuaranteed, "",
+		},
+		{
+			"request different from the limit",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+						Resources: &corev1.ResourceRequirements{
+							Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore, "memory": &oneGi},
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+						},
+					},
+				},
+			},
+			nil, qosClassBurstable, "container 'app' cpu request '500m' is not equal to the limit '1'",
+		},
+		{
+			"init container without a memory limit",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name: &appName,
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore, "memory": &oneGi},
+						},
+					},
+				},
+				InitContainers: []*corev1.Container{
+					{
+						Name: &initName,
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+						},
+					},
+				},
+			},
+			nil, qosClassBurstable, "container 'init' does not have a memory limit",
+		},
+		{
+			"pod-level resources",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{{Name: &appName}},
+			},
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore, "memory": &oneGi},
+			},
+			qosClassGuaranteed, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			qosClass, reason, err := podQOSClass(&test.podSpec, test.podResources)
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if qosClass != test.expectedQOSClass {
+				t.Fatalf("invalid QoS class. Expected '%s', got '%s'", test.expectedQOSClass, qosClass)
+			}
+			if reason != test.expectedReason {
+				t.Fatalf("invalid reason. Expected '%s', got '%s'", test.expectedReason, reason)
+			}
+		})
+	}
+}
+
+func TestQOSClassValidation(t *testing.T) {
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	halfCore := apimachinery_pkg_api_resource.Quantity("500m")
+	appName := "app"
+
+	tests := []struct {
+		name             string
+		resources        *corev1.ResourceRequirements
+		settings         Settings
+		expectedErrorMsg string
+	}{
+		{
+			"guaranteed after applying the defaults",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			Settings{
+				Memory: &ResourceConfiguration{
+					DefaultLimit:   quantityPtr("1Gi"),
+					DefaultRequest: quantityPtr("1Gi"),
+				},
+				QosClass: &QosClassConfiguration{Allowed: []string{qosClassGuaranteed}},
+			},
+			"",
+		},
+		{
+			"burstable not allowed",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			Settings{
+				QosClass: &QosClassConfiguration{Allowed: []string{qosClassGuaranteed}},
+			},
+			"the Pod QoS class 'Burstable' is not allowed\ncontainer 'app' cpu request '500m' is not equal to the limit '1'",
+		},
+		{
+			"best effort forbidden",
+			nil,
+			Settings{
+				QosClass: &QosClassConfiguration{Minimum: qosClassBurstable},
+			},
+			"the Pod QoS class 'BestEffort' is not allowed\nnone of the containers has cpu or memory requests or limits",
+		},
+		{
+			"burstable allowed by the minimum",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			Settings{
+				QosClass: &QosClassConfiguration{Minimum: qosClassBurstable},
+			},
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Name: &appName, Image: "image:latest", Resources: test.resources}},
+			}
+			_, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+)
+
+// ratioDefaultScale returns the scale of the default values derived from a
+// ratio: whole bytes for the resources expressed in bytes, milli-units for the
+// other ones.
+func ratioDefaultScale(resourceName string) resource.Scale {
+	if isByteResourceName(resourceName) {
+		return 0
+	}
+	return resource.Milli
+}
+
+// scaleByRatio multiplies the quantity of the given resource by the ratio. The
+// result is rounded up to the scale of the resource, or to the step when it's
+// configured, and it keeps the format of the quantity. The cpu is rounded up
+// to whole cores when integerCpu requires it.
+func (r *ResourceConfiguration) scaleByRatio(resourceName string, quantity, ratio resource.Quantity) resource.Quantity {
+	scaled := quantity.DeepCopy()
+	// The quantities are not more precise than the nano scale
+	scaled.MulRatio(ratio.ScaledValue(resource.Nano), 1e9)
+	scaled.RoundUp(ratioDefaultScale(resourceName))
+	if r.Step != nil {
+		scaled = roundUpToStep(scaled, *r.Step)
+	}
+	if r.roundUpDerivedToCores {
+		return *roundUpToCores(&scaled)
+	}
+	return scaled
+}
+
+func (r *ResourceConfiguration) validRatioDefaults() error {
+	if r.DefaultLimitFromRequestRatio == nil && r.DefaultRequestFromLimitRatio == nil {
+		return nil
+	}
+	if r.IgnoreValues {
+		return errors.New("defaultLimitFromRequestRatio and defaultRequestFromLimitRatio cannot be defined when ignoreValues is set")
+	}
+	if r.limitForbidden() {
+		return errors.New("defaultLimitFromRequestRatio and defaultRequestFromLimitRatio cannot be defined when the limits are forbidden")
+	}
+	if r.DefaultLimitFromRequestRatio != nil {
+		if r.DefaultLimitFromRequestRatio.Cmp(resource.MustParse("1")) < 0 {
+			return fmt.Errorf("default limit from request ratio: %s cannot be less than 1", r.DefaultLimitFromRequestRatio.String())
+		}
+		if r.MaxLimitRequestRatio != nil && r.DefaultLimitFromRequestRatio.Cmp(*r.MaxLimitRequestRatio) > 0 {
+			return fmt.Errorf("default limit from request ratio: %s cannot be greater than max limit request ratio: %s", r.DefaultLimitFromRequestRatio.String(), r.MaxLimitRequestRatio.String())
+		}
+	}
+	if r.DefaultRequestFromLimitRatio != nil && (r.DefaultRequestFromLimitRatio.Sign() <= 0 || r.DefaultRequestFromLimitRatio.Cmp(resource.MustParse("1")) > 0) {
+		return fmt.Errorf("default request from limit ratio: %s must be greater than 0 and not greater than 1", r.DefaultRequestFromLimitRatio.String())
+	}
+	return nil
+}
+
+// defaultLimit returns the limit injected into the container missing the
+// limit of the given resource, and whether it has been derived from a
+// request. When DefaultLimitFromRequestRatio is configured, the limit is
+// derived from the request of the container. The fixed DefaultLimit applies
+// when the request is missing too, otherwise the limit is derived from the
+// DefaultRequest injected later.
+func (r *ResourceConfiguration) defaultLimit(container *corev1.Container, resourceName string) (*resource.Quantity, bool, error) {
+	if r.DefaultLimitFromRequestRatio == nil {
+		return r.DefaultLimit, false, nil
+	}
+	if !missingResourceQuantity(container.Resources.Requests, resourceName) {
+		request, err := parseResourceQuantity(container.Resources.Requests, resourceName, "request")
+		if err != nil {
+			return nil, false, err
+		}
+		limit := r.scaleByRatio(resourceName, request, *r.DefaultLimitFromRequestRatio)
+		return &limit, true, nil
+	}
+	if r.DefaultLimit == nil && r.DefaultRequest != nil {
+		limit := r.scaleByRatio(resourceName, *r.DefaultRequest, *r.DefaultLimitFromRequestRatio)
+		return &limit, true, nil
+	}
+	return r.DefaultLimit, false, nil
+}
+
+// defaultRequest returns the request injected into the container missing the
+// request of the given resource, and whether it has been derived from the
+// limit. When DefaultRequestFromLimitRatio is configured, the request is
+// derived from the limit of the container. The fixed DefaultRequest applies
+// when the limit has been injected by the policy, otherwise the request is
+// derived from the injected limit.
+func (r *ResourceConfiguration) defaultRequest(container *corev1.Container, resourceName string, limitInjected bool) (*resource.Quantity, bool, error) {
+	if r.DefaultRequestFromLimitRatio == nil ||
+		missingResourceQuantity(container.Resources.Limits, resourceName) ||
+		(limitInjected && r.DefaultRequest != nil) {
+		return r.DefaultRequest, false, nil
+	}
+	limit, err := parseResourceQuantity(container.Resources.Limits, resourceName, "limit")
+	if err != nil {
+		return nil, false, err
+	}
+	request := r.scaleByRatio(resourceName, limit, *r.DefaultRequestFromLimitRatio)
+	return &request, true, nil
+}
@@ -0,0 +1,143 @@
+package resource
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+// List holds the quantities of a set of resources by resource name, like the
+// ResourceList of the Kubernetes core API. The operations never mutate their
+// operands, and the quantities of the results keep the format of the
+// operands.
+type List map[string]Quantity
+
+// ParseList parses the quantities of the given k8s-objects resource map. The
+// missing and empty quantities are skipped.
+func ParseList(quantities map[string]*api_resource.Quantity) (List, error) {
+	list := List{}
+	for _, name := range sortedNames(quantities) {
+		rawQuantity := quantities[name]
+		if rawQuantity == nil || len(strings.TrimSpace(string(*rawQuantity))) == 0 {
+			continue
+		}
+		quantity, err := ParseQuantity(string(*rawQuantity))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s quantity '%s'", name, string(*rawQuantity))
+		}
+		list[name] = quantity
+	}
+	return list, nil
+}
+
+// ToMap returns the list as a k8s-objects resource map, holding the canonical
+// form of the quantities.
+func (l List) ToMap() map[string]*api_resource.Quantity {
+	quantities := make(map[string]*api_resource.Quantity, len(l))
+	for name, quantity := range l {
+		rawQuantity := api_resource.Quantity(quantity.String())
+		quantities[name] = &rawQuantity
+	}
+	return quantities
+}
+
+// DeepCopy returns a deep copy of the list. The quantities are deep copied,
+// because a Quantity could share its inf.Dec with its copies.
+func (l List) DeepCopy() List {
+	list := make(List, len(l))
+	for name, quantity := range l {
+		list[name] = quantity.DeepCopy()
+	}
+	return list
+}
+
+// Names returns the sorted names of the resources of the list.
+func (l List) Names() []string {
+	return sortedNames(l)
+}
+
+// Add returns the sum of the lists. The resources missing from one of the
+// lists are copied from the other one.
+func (l List) Add(other List) List {
+	result := l.DeepCopy()
+	for name, quantity := range other {
+		sum := result[name]
+		sum.Add(quantity)
+		result[name] = sum
+	}
+	return result
+}
+
+// Sub returns the difference between the lists. The resources missing from l
+// are subtracted from zero.
+func (l List) Sub(other List) List {
+	result := l.DeepCopy()
+	for name, quantity := range other {
+		difference := result[name]
+		difference.Sub(quantity)
+		result[name] = difference
+	}
+	return result
+}
+
+// Max returns the max of the lists by resource. The resources missing from one
+// of the lists are copied from the other one.
+func (l List) Max(other List) List {
+	result := l.DeepCopy()
+	for name, quantity := range other {
+		if current, found := result[name]; !found || quantity.Cmp(current) > 0 {
+			result[name] = quantity.DeepCopy()
+		}
+	}
+	return result
+}
+
+// Min returns the min of the lists by resource. The resources missing from one
+// of the lists are not bounded by it, therefore they are copied from the other
+// one.
+func (l List) Min(other List) List {
+	result := l.DeepCopy()
+	for name, quantity := range other {
+		if current, found := result[name]; !found || quantity.Cmp(current) < 0 {
+			result[name] = quantity.DeepCopy()
+		}
+	}
+	return result
+}
+
+// LessOrEqual returns true when every resource of l is less than or equal to
+// the same resource of the other list, otherwise it returns the sorted names of
+// the greater resources. The resources missing from the other list are not
+// compared.
+func (l List) LessOrEqual(other List) (bool, []string) {
+	greater := []string{}
+	for _, name := range l.Names() {
+		quantity := l[name]
+		if bound, found := other[name]; found && quantity.Cmp(bound) > 0 {
+			greater = append(greater, name)
+		}
+	}
+	return len(greater) == 0, greater
+}
+
+// Missing returns the given resource names which are not in the list.
+func (l List) Missing(names ...string) []string {
+	missing := []string{}
+	for _, name := range names {
+		if _, found := l[name]; !found {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func sortedNames[T any](resources map[string]T) []string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
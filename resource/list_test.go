@@ -0,0 +1,106 @@
+package resource
+
+import (
+	"reflect"
+	"testing"
+
+	api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+func listStrings(l List) map[string]string {
+	result := make(map[string]string, len(l))
+	for name, quantity := range l {
+		result[name] = quantity.String()
+	}
+	return result
+}
+
+func TestParseList(t *testing.T) {
+	cpu := api_resource.Quantity("0.5")
+	memory := api_resource.Quantity("1024Mi")
+	empty := api_resource.Quantity(" ")
+	invalid := api_resource.Quantity("1x")
+
+	list, err := ParseList(map[string]*api_resource.Quantity{"cpu": &cpu, "memory": &memory, "ephemeral-storage": &empty, "nvidia.com/gpu": nil})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"cpu": "500m", "memory": "1Gi"}
+	if !reflect.DeepEqual(listStrings(list), expected) {
+		t.Errorf("Expected %v, got %v", expected, listStrings(list))
+	}
+
+	if _, err := ParseList(map[string]*api_resource.Quantity{"cpu": &invalid}); err == nil || err.Error() != "invalid cpu quantity '1x'" {
+		t.Errorf("Expected invalid cpu quantity error, got %v", err)
+	}
+}
+
+func TestListToMap(t *testing.T) {
+	list := List{"cpu": MustParse("0.5"), "memory": MustParse("1024Mi")}
+	quantities := list.ToMap()
+	if len(quantities) != 2 || *quantities["cpu"] != "500m" || *quantities["memory"] != "1Gi" {
+		t.Errorf("Expected the canonical quantities, got cpu %v and memory %v", *quantities["cpu"], *quantities["memory"])
+	}
+}
+
+func TestListOperations(t *testing.T) {
+	a := List{"cpu": MustParse("1"), "memory": MustParse("1Gi")}
+	b := List{"cpu": MustParse("500m"), "ephemeral-storage": MustParse("2Gi")}
+
+	tests := []struct {
+		name     string
+		result   List
+		expected map[string]string
+	}{
+		{"add", a.Add(b), map[string]string{"cpu": "1500m", "memory": "1Gi", "ephemeral-storage": "2Gi"}},
+		{"sub", a.Sub(b), map[string]string{"cpu": "500m", "memory": "1Gi", "ephemeral-storage": "-2Gi"}},
+		{"max", a.Max(b), map[string]string{"cpu": "1", "memory": "1Gi", "ephemeral-storage": "2Gi"}},
+		{"min", a.Min(b), map[string]string{"cpu": "500m", "memory": "1Gi", "ephemeral-storage": "2Gi"}},
+		{"empty", List{}.Add(List{}), map[string]string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !reflect.DeepEqual(listStrings(test.result), test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, listStrings(test.result))
+			}
+		})
+	}
+
+	// The operands are not mutated
+	expected := map[string]string{"cpu": "1", "memory": "1Gi"}
+	if !reflect.DeepEqual(listStrings(a), expected) {
+		t.Errorf("Expected %v, got %v", expected, listStrings(a))
+	}
+}
+
+func TestListLessOrEqual(t *testing.T) {
+	bound := List{"cpu": MustParse("1"), "memory": MustParse("1Gi")}
+
+	tests := []struct {
+		list     List
+		expected bool
+		greater  []string
+	}{
+		{List{"cpu": MustParse("1"), "memory": MustParse("512Mi")}, true, []string{}},
+		{List{"cpu": MustParse("2"), "memory": MustParse("2Gi")}, false, []string{"cpu", "memory"}},
+		{List{"ephemeral-storage": MustParse("10Gi")}, true, []string{}},
+		{List{}, true, []string{}},
+	}
+
+	for i, test := range tests {
+		lessOrEqual, greater := test.list.LessOrEqual(bound)
+		if lessOrEqual != test.expected || !reflect.DeepEqual(greater, test.greater) {
+			t.Errorf("[%d] Expected %t %v, got %t %v", i, test.expected, test.greater, lessOrEqual, greater)
+		}
+	}
+}
+
+func TestListMissing(t *testing.T) {
+	list := List{"cpu": MustParse("1")}
+	missing := list.Missing("cpu", "memory", "ephemeral-storage")
+	expected := []string{"memory", "ephemeral-storage"}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("Expected %v, got %v", expected, missing)
+	}
+}
@@ -0,0 +1,93 @@
+package resource
+
+import (
+	inf "gopkg.in/inf.v0"
+)
+
+// Mul multiplies the current amount by b, or returns false and does not mutate a if overflow
+// or underflow would result.
+func (a *int64Amount) Mul(b int64) bool {
+	c, ok := int64Multiply(a.value, b)
+	if !ok {
+		return false
+	}
+	a.value = c
+	return true
+}
+
+// Quo divides the current amount by b, or returns false and does not mutate a if the result
+// is not exact or would overflow. b must not be zero.
+func (a *int64Amount) Quo(b int64) bool {
+	if a.value%b != 0 || (a.value == mostNegative && b == -1) {
+		return false
+	}
+	a.value = a.value / b
+	return true
+}
+
+// Div returns the ratio of a to b, or false if the ratio cannot be represented exactly by an
+// int64Amount. b must not be zero.
+func (a int64Amount) Div(b int64Amount) (int64Amount, bool) {
+	if a.value%b.value != 0 || (a.value == mostNegative && b.value == -1) {
+		return int64Amount{}, false
+	}
+	return int64Amount{value: a.value / b.value, scale: a.scale - b.scale}, true
+}
+
+// decQuoRoundUp returns x / y, and true if the result is exact. The result which is not exact
+// is rounded away from zero to the nano scale, or to the scale of x when x is more precise.
+// y must not be zero.
+func decQuoRoundUp(x, y *inf.Dec) (*inf.Dec, bool) {
+	scale := Nano.infScale()
+	if x.Scale() > scale {
+		scale = x.Scale()
+	}
+	result := new(inf.Dec).QuoRound(x, y, scale, inf.RoundUp)
+	return result, new(inf.Dec).Mul(result, y).Cmp(x) == 0
+}
+
+// Mul multiplies the current value by y in place.
+func (q *Quantity) Mul(y int64) {
+	q.s = ""
+	if q.d.Dec == nil && q.i.Mul(y) {
+		return
+	}
+	q.ToDec().d.Mul(q.d.Dec, inf.NewDec(y, 0))
+}
+
+// MulRatio multiplies the current value by num / den in place, and returns true if the result
+// is exact. The result which is not exact is rounded away from zero to the nano scale, or to
+// the scale of the quantity when it is more precise. den must not be zero.
+func (q *Quantity) MulRatio(num, den int64) bool {
+	q.s = ""
+	if q.d.Dec == nil {
+		i := q.i
+		if i.Mul(num) && i.Quo(den) {
+			q.i = i
+			return true
+		}
+	}
+	product := new(inf.Dec).Mul(q.AsDec(), inf.NewDec(num, 0))
+	result, exact := decQuoRoundUp(product, inf.NewDec(den, 0))
+	q.d.Dec = result
+	return exact
+}
+
+// Percent sets the quantity to the given percentage of its value, and returns true if the
+// result is exact. The result is rounded like MulRatio does.
+func (q *Quantity) Percent(percent int64) bool {
+	return q.MulRatio(percent, 100)
+}
+
+// Div returns the ratio of the quantity to y in DecimalSI format, and true if the ratio is
+// exact. The ratio which is not exact is rounded away from zero to the nano scale, or to the
+// scale of the quantity when it is more precise. y must not be zero.
+func (q *Quantity) Div(y Quantity) (Quantity, bool) {
+	if q.d.Dec == nil && y.d.Dec == nil {
+		if ratio, ok := q.i.Div(y.i); ok {
+			return Quantity{i: ratio, Format: DecimalSI}, true
+		}
+	}
+	ratio, exact := decQuoRoundUp(q.AsDec(), y.AsDec())
+	return Quantity{d: infDecAmount{ratio}, Format: DecimalSI}, exact
+}
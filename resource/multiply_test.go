@@ -0,0 +1,106 @@
+package resource
+
+import (
+	"testing"
+)
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		a        Quantity
+		b        int64
+		expected string
+	}{
+		{intQuantity(500, -3, DecimalSI), 2, "1"},
+		{intQuantity(1073741824, 0, BinarySI), 3, "3Gi"},
+		{decQuantity(15, -1, DecimalSI), 3, "4500m"},
+		{intQuantity(mostPositive, 0, DecimalSI), 2, "18446744073709551614"},
+		{intQuantity(10, 0, DecimalSI), -1, "-10"},
+		{Quantity{Format: DecimalSI}, 5, "0"},
+	}
+
+	for i, test := range tests {
+		test.a.Mul(test.b)
+		if test.a.String() != test.expected {
+			t.Errorf("[%d] Expected %q, got %q", i, test.expected, test.a.String())
+		}
+	}
+}
+
+func TestMulRatio(t *testing.T) {
+	tests := []struct {
+		a        Quantity
+		num      int64
+		den      int64
+		expected string
+		exact    bool
+	}{
+		{intQuantity(1073741824, 0, BinarySI), 1, 2, "512Mi", true},
+		{intQuantity(1, 0, DecimalSI), 1, 2, "500m", true},
+		{intQuantity(300, -3, DecimalSI), 3, 2, "450m", true},
+		{decQuantity(1, 0, DecimalSI), 2, 4, "500m", true},
+		{intQuantity(1, 0, DecimalSI), 1, 3, "333333334n", false},
+		{intQuantity(-1, 0, DecimalSI), 1, 3, "-333333334n", false},
+		{intQuantity(mostPositive, 0, DecimalSI), 4, 2, "18446744073709551614", true},
+	}
+
+	for i, test := range tests {
+		exact := test.a.MulRatio(test.num, test.den)
+		if test.a.String() != test.expected {
+			t.Errorf("[%d] Expected %q, got %q", i, test.expected, test.a.String())
+		}
+		if exact != test.exact {
+			t.Errorf("[%d] Expected exact %t, got %t", i, test.exact, exact)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		a        Quantity
+		percent  int64
+		expected string
+		exact    bool
+	}{
+		{intQuantity(2, 0, DecimalSI), 50, "1", true},
+		{intQuantity(1073741824, 0, BinarySI), 25, "256Mi", true},
+		{intQuantity(4, 0, DecimalSI), 150, "6", true},
+		{intQuantity(1, -9, DecimalSI), 50, "1n", false},
+	}
+
+	for i, test := range tests {
+		exact := test.a.Percent(test.percent)
+		if test.a.String() != test.expected {
+			t.Errorf("[%d] Expected %q, got %q", i, test.expected, test.a.String())
+		}
+		if exact != test.exact {
+			t.Errorf("[%d] Expected exact %t, got %t", i, test.exact, exact)
+		}
+	}
+}
+
+func TestDiv(t *testing.T) {
+	tests := []struct {
+		a        Quantity
+		b        Quantity
+		expected string
+		exact    bool
+	}{
+		{intQuantity(4, 0, DecimalSI), intQuantity(2, 0, DecimalSI), "2", true},
+		{intQuantity(1, 0, DecimalSI), intQuantity(500, -3, DecimalSI), "2", true},
+		{intQuantity(1073741824, 0, BinarySI), intQuantity(1048576, 0, BinarySI), "1024", true},
+		{intQuantity(3, 0, DecimalSI), intQuantity(2, 0, DecimalSI), "1500m", true},
+		{intQuantity(1, 0, DecimalSI), intQuantity(3, 0, DecimalSI), "333333334n", false},
+		{decQuantity(15, -1, DecimalSI), intQuantity(3, -1, DecimalSI), "5", true},
+		{intQuantity(-6, 0, DecimalSI), intQuantity(3, 0, DecimalSI), "-2", true},
+	}
+
+	for i, test := range tests {
+		ratio, exact := test.a.Div(test.b)
+		if ratio.String() != test.expected {
+			t.Errorf("[%d] Expected %q, got %q", i, test.expected, ratio.String())
+		}
+		if exact != test.exact {
+			t.Errorf("[%d] Expected exact %t, got %t", i, test.exact, exact)
+		}
+	}
+}
@@ -4,26 +4,148 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
 	kubewarden "github.com/kubewarden/policy-sdk-go"
 	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
 )
 
 type ResourceConfiguration struct {
-	MinLimit       resource.Quantity `json:"minLimit"`
-	MaxLimit       resource.Quantity `json:"maxLimit"`
-	MinRequest     resource.Quantity `json:"minRequest"`
-	MaxRequest     resource.Quantity `json:"maxRequest"`
-	DefaultRequest resource.Quantity `json:"defaultRequest"`
-	DefaultLimit   resource.Quantity `json:"defaultLimit"`
-	IgnoreValues   bool              `json:"ignoreValues,omitempty"`
+	// The quantities are nil when not configured, an explicit zero is a
+	// valid value: for example, a max limit and a max request of zero forbid
+	// the resource.
+	MinLimit       *resource.Quantity `json:"minLimit,omitempty"`
+	MaxLimit       *resource.Quantity `json:"maxLimit,omitempty"`
+	MinRequest     *resource.Quantity `json:"minRequest,omitempty"`
+	MaxRequest     *resource.Quantity `json:"maxRequest,omitempty"`
+	DefaultRequest *resource.Quantity `json:"defaultRequest,omitempty"`
+	DefaultLimit   *resource.Quantity `json:"defaultLimit,omitempty"`
+	IgnoreValues   bool               `json:"ignoreValues,omitempty"`
+	// MaxLimitRequestRatio and MaxLimitRequestDelta bound the overcommit of
+	// the resource, like the LimitRange maxLimitRequestRatio does.
+	MaxLimitRequestRatio *resource.Quantity `json:"maxLimitRequestRatio,omitempty"`
+	MaxLimitRequestDelta *resource.Quantity `json:"maxLimitRequestDelta,omitempty"`
+	// RequireRequestEqualsLimit rejects the containers whose request is not
+	// equal to the limit. When MirrorRequestAndLimit is true, the missing
+	// request is copied from the limit, and the missing limit is copied from
+	// the request, instead of applying the default values.
+	RequireRequestEqualsLimit bool `json:"requireRequestEqualsLimit,omitempty"`
+	MirrorRequestAndLimit     bool `json:"mirrorRequestAndLimit,omitempty"`
+	// LimitPolicy defines whether the limit of the resource is required,
+	// optional (default) or forbidden. ForbiddenLimitAction defines whether
+	// the forbidden limits are rejected (default) or removed.
+	LimitPolicy          string `json:"limitPolicy,omitempty"`
+	ForbiddenLimitAction string `json:"forbiddenLimitAction,omitempty"`
+	// Step defines the granularity of the resource quantities. StepMode
+	// defines whether the quantities which are not a multiple of the step
+	// are rejected (default) or rounded up.
+	Step     *resource.Quantity `json:"step,omitempty"`
+	StepMode string             `json:"stepMode,omitempty"`
+	// RejectZero handles the zero limits and requests like the missing
+	// ones: they are replaced by the default values, or rejected.
+	RejectZero bool `json:"rejectZero,omitempty"`
+	// OnViolation defines whether the limits and requests out of the min and
+	// max values are rejected (default), or clamped to the nearest bound.
+	OnViolation string `json:"onViolation,omitempty"`
+	// RepairStrategy defines how a limit lower than the request, caused by
+	// an injected default value, is repaired instead of being rejected: by
+	// raising the injected limit to the request, or by lowering the injected
+	// request to the limit.
+	RepairStrategy string `json:"repairStrategy,omitempty"`
+	// DefaultLimitFromRequestRatio and DefaultRequestFromLimitRatio derive
+	// the missing limit from the request, and the missing request from the
+	// limit. DefaultLimit and DefaultRequest apply when both are missing.
+	DefaultLimitFromRequestRatio *resource.Quantity `json:"defaultLimitFromRequestRatio,omitempty"`
+	DefaultRequestFromLimitRatio *resource.Quantity `json:"defaultRequestFromLimitRatio,omitempty"`
+	// roundUpDerivedToCores rounds the default values derived from a ratio up
+	// to whole cores. It's set by integerCpu when roundUpDefaults is enabled.
+	roundUpDerivedToCores bool
 }
 
+const (
+	limitPolicyRequired  = "required"
+	limitPolicyOptional  = "optional"
+	limitPolicyForbidden = "forbidden"
+
+	forbiddenLimitActionReject = "reject"
+	forbiddenLimitActionRemove = "remove"
+
+	defaultingModePolicy     = "policy"
+	defaultingModeKubernetes = "kubernetes"
+
+	onViolationReject = "reject"
+	onViolationClamp  = "clamp"
+
+	repairStrategyRaiseLimit   = "raiseLimit"
+	repairStrategyLowerRequest = "lowerRequest"
+)
+
 type Settings struct {
-	Cpu          *ResourceConfiguration `json:"cpu,omitempty"`
-	Memory       *ResourceConfiguration `json:"memory,omitempty"`
-	IgnoreImages []string               `json:"ignoreImages,omitempty"`
+	// Cpu, Memory and EphemeralStorage are aliases of the cpu, memory and
+	// ephemeral-storage entries of Resources, kept for backward
+	// compatibility.
+	Cpu              *ResourceConfiguration `json:"cpu,omitempty"`
+	Memory           *ResourceConfiguration `json:"memory,omitempty"`
+	EphemeralStorage *ResourceConfiguration `json:"ephemeralStorage,omitempty"`
+	// Resources defines the constraints of the container resources, by
+	// resource name.
+	Resources    map[string]*ResourceConfiguration `json:"resources,omitempty"`
+	IgnoreImages []string                          `json:"ignoreImages,omitempty"`
+	// Hugepages defines the constraints of the hugepages resources, by page
+	// size (for example `2Mi` or `1Gi`). When it is provided, the Kubernetes
+	// hugepages invariants are enforced on all the page sizes.
+	Hugepages map[string]*ResourceConfiguration `json:"hugepages,omitempty"`
+	// ExtendedResources defines the constraints of the extended resources,
+	// like `nvidia.com/gpu`, by resource name.
+	ExtendedResources map[string]*ResourceConfiguration `json:"extendedResources,omitempty"`
+	// RequireLimitsWithExtendedResources requires a cpu and a memory limit
+	// on the containers using one of the ExtendedResources.
+	RequireLimitsWithExtendedResources bool `json:"requireLimitsWithExtendedResources,omitempty"`
+	// CheckEmptyDirSizeLimit enables the check of the emptyDir volumes size
+	// limit against the ephemeral-storage limit of the containers mounting
+	// them.
+	CheckEmptyDirSizeLimit bool `json:"checkEmptyDirSizeLimit,omitempty"`
+	// InitContainers optionally overrides the settings used for the init
+	// containers. When it is not provided, the init containers are validated
+	// using the same settings as the app containers.
+	InitContainers *Settings `json:"initContainers,omitempty"`
+	// Sidecar optionally overrides the settings used for the native sidecar
+	// containers (init containers with restartPolicy: Always). When it is not
+	// provided, the sidecar containers are validated using the same settings
+	// as the app containers.
+	Sidecar *Settings `json:"sidecar,omitempty"`
+	// EphemeralContainers enables the validation of the ephemeral containers
+	// added to a Pod by the pods/ephemeralcontainers subresource. The
+	// ephemeral containers are not validated when it is not provided.
+	EphemeralContainers *Settings `json:"ephemeralContainers,omitempty"`
+	// Pod enables the validation of the pod-level resources
+	// (PodSpec.resources). The pod-level resources are not validated when it
+	// is not provided.
+	Pod *Settings `json:"pod,omitempty"`
+	// MaxPodRequest and MaxPodLimit define the max effective resources of the
+	// Pod, computed like the kube-scheduler does, including the Pod overhead.
+	MaxPodRequest resource.List `json:"maxPodRequest,omitempty"`
+	MaxPodLimit   resource.List `json:"maxPodLimit,omitempty"`
+	// QosClass defines the QoS classes allowed for the Pods, computed after
+	// applying the default values.
+	QosClass *QosClassConfiguration `json:"qosClass,omitempty"`
+	// IntegerCpu requires whole cores for the cpu requests and limits of the
+	// selected Pods.
+	IntegerCpu *IntegerCpuConfiguration `json:"integerCpu,omitempty"`
+	// UnitHygiene rejects the quantities whose unit is almost certainly a
+	// mistake. The sections use the top level configuration.
+	UnitHygiene *UnitHygieneConfiguration `json:"unitHygiene,omitempty"`
+	// Canonicalize rewrites the container limits and requests to their
+	// canonical form. The sections use the top level configuration.
+	Canonicalize *CanonicalizeConfiguration `json:"canonicalize,omitempty"`
+	// DefaultingMode defines how the missing requests are defaulted. The
+	// policy mode (default) injects the default request, while the kubernetes
+	// mode copies the limit into the missing request before validating the
+	// containers, like the API server does. The sections use the top level
+	// configuration.
+	DefaultingMode string `json:"defaultingMode,omitempty"`
 }
 
 type AllValuesAreZeroError struct{}
@@ -32,128 +154,617 @@ func (e AllValuesAreZeroError) Error() string {
 	return "all the quantities must be defined"
 }
 
-func (s *Settings) shouldIgnoreCpuValues() bool {
-	return s.Cpu != nil && (s.Cpu.IgnoreValues || (!s.Cpu.IgnoreValues && s.Cpu.allValuesAreZero()))
+// resourceSettings is the configuration of a container resource.
+type resourceSettings struct {
+	// resourceName is the name of the resource, like `ephemeral-storage`
+	resourceName string
+	// settingsName is the name of the resource in the settings, like
+	// `ephemeralStorage`
+	settingsName  string
+	configuration *ResourceConfiguration
 }
 
-func (s *Settings) shouldIgnoreMemoryValues() bool {
-	return s.Memory != nil && (s.Memory.IgnoreValues || (!s.Memory.IgnoreValues && s.Memory.allValuesAreZero()))
+// resourceAliases returns the resources which can be configured using a
+// dedicated field of the settings, in place of the Resources map.
+func (s *Settings) resourceAliases() []resourceSettings {
+	return []resourceSettings{
+		{"cpu", "cpu", s.Cpu},
+		{"memory", "memory", s.Memory},
+		{"ephemeral-storage", "ephemeralStorage", s.EphemeralStorage},
+	}
+}
+
+func (s *Settings) isResourceAlias(resourceName string) bool {
+	for _, alias := range s.resourceAliases() {
+		if alias.resourceName == resourceName {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceSettings returns the configured container resources. The resources
+// having an alias come first, followed by the other resources sorted by
+// name.
+func (s *Settings) resourceSettings() []resourceSettings {
+	resources := []resourceSettings{}
+	for _, alias := range s.resourceAliases() {
+		if alias.configuration != nil {
+			resources = append(resources, alias)
+		} else if configuration := s.Resources[alias.resourceName]; configuration != nil {
+			resources = append(resources, resourceSettings{alias.resourceName, alias.resourceName, configuration})
+		}
+	}
+	for _, resourceName := range sortedResourceNames(s.Resources) {
+		if configuration := s.Resources[resourceName]; configuration != nil && !s.isResourceAlias(resourceName) {
+			resources = append(resources, resourceSettings{resourceName, resourceName, configuration})
+		}
+	}
+	return resources
+}
+
+// resourceConfiguration returns the configuration of the given resource, or
+// nil when the resource is not configured.
+func (s *Settings) resourceConfiguration(resourceName string) *ResourceConfiguration {
+	for _, resourceSettings := range s.resourceSettings() {
+		if resourceSettings.resourceName == resourceName {
+			return resourceSettings.configuration
+		}
+	}
+	return nil
+}
+
+// shouldIgnoreValues returns true when only the presence of the given
+// resource must be checked, because IgnoreValues is set or no quantity is
+// configured.
+func (s *Settings) shouldIgnoreValues(resourceName string) bool {
+	configuration := s.resourceConfiguration(resourceName)
+	return configuration != nil && (configuration.IgnoreValues || configuration.allValuesAreUnset())
+}
+
+// requiredResources returns the names of the resources whose presence must
+// be checked.
+func (s *Settings) requiredResources() []string {
+	requiredResources := []string{}
+	for _, resourceSettings := range s.resourceSettings() {
+		if s.shouldIgnoreValues(resourceSettings.resourceName) {
+			requiredResources = append(requiredResources, resourceSettings.resourceName)
+		}
+	}
+	return requiredResources
 }
 
 func (r *ResourceConfiguration) valid() error {
-	if r.allValuesAreZero() && !r.IgnoreValues {
+	if r.allValuesAreUnset() && !r.IgnoreValues {
 		return AllValuesAreZeroError{}
 	}
 
+	if r.MaxLimitRequestRatio != nil && r.MaxLimitRequestRatio.Cmp(resource.MustParse("1")) < 0 {
+		return fmt.Errorf("max limit request ratio: %s cannot be less than 1", r.MaxLimitRequestRatio.String())
+	}
+	if r.MaxLimitRequestDelta != nil && r.MaxLimitRequestDelta.Sign() < 0 {
+		return fmt.Errorf("max limit request delta: %s cannot be negative", r.MaxLimitRequestDelta.String())
+	}
+	if err := r.validLimitPolicy(); err != nil {
+		return err
+	}
+	if err := r.validStep(); err != nil {
+		return err
+	}
+	if err := r.validRatioDefaults(); err != nil {
+		return err
+	}
+	switch r.OnViolation {
+	case "", onViolationReject, onViolationClamp:
+	default:
+		return fmt.Errorf("invalid onViolation value '%s'. Valid values are %s and %s", r.OnViolation, onViolationReject, onViolationClamp)
+	}
+	if r.OnViolation != "" && r.IgnoreValues {
+		return errors.New("onViolation cannot be defined when ignoreValues is set")
+	}
+	switch r.RepairStrategy {
+	case "", repairStrategyRaiseLimit, repairStrategyLowerRequest:
+	default:
+		return fmt.Errorf("invalid repair strategy '%s'. Valid values are %s and %s", r.RepairStrategy, repairStrategyRaiseLimit, repairStrategyLowerRequest)
+	}
+	if r.RepairStrategy != "" && r.IgnoreValues {
+		return errors.New("repairStrategy cannot be defined when ignoreValues is set")
+	}
+	if r.RejectZero && ((r.DefaultRequest != nil && r.DefaultRequest.IsZero()) || (r.DefaultLimit != nil && r.DefaultLimit.IsZero())) {
+		return errors.New("default request and default limit cannot be zero when rejectZero is set")
+	}
+	if r.MirrorRequestAndLimit && !r.RequireRequestEqualsLimit {
+		return errors.New("mirrorRequestAndLimit requires requireRequestEqualsLimit")
+	}
+	if r.RequireRequestEqualsLimit && r.DefaultRequest != nil && r.DefaultLimit != nil && r.DefaultRequest.Cmp(*r.DefaultLimit) != 0 {
+		return fmt.Errorf("default request: %s must be equal to default limit: %s, because requireRequestEqualsLimit is set", r.DefaultRequest.String(), r.DefaultLimit.String())
+	}
+	if r.DefaultRequest != nil && r.DefaultLimit != nil {
+		if err := validateLimitRequestRatio(*r.DefaultLimit, *r.DefaultRequest, r); err != nil {
+			return errors.Join(errors.New("default limit and default request are not valid"), err)
+		}
+	}
+
 	// Core chain: minRequest <= defaultRequest <= maxRequest <= minLimit <= defaultLimit <= maxLimit
 	// This enforces the constraint: limit >= request for all combinations
 	// The chain ensures that any limit is always >= any request
 
 	// Validate max limit relationships
 	// defaultLimit <= maxLimit
-	if !r.DefaultLimit.IsZero() && !r.MaxLimit.IsZero() && r.DefaultLimit.Cmp(r.MaxLimit) > 0 {
+	if r.DefaultLimit != nil && r.MaxLimit != nil && r.DefaultLimit.Cmp(*r.MaxLimit) > 0 {
 		return fmt.Errorf("default limit: %s cannot be greater than max limit: %s", r.DefaultLimit.String(), r.MaxLimit.String())
 	}
 	// minLimit <= maxLimit
-	if !r.MinLimit.IsZero() && !r.MaxLimit.IsZero() && r.MinLimit.Cmp(r.MaxLimit) > 0 {
+	if r.MinLimit != nil && r.MaxLimit != nil && r.MinLimit.Cmp(*r.MaxLimit) > 0 {
 		return fmt.Errorf("min limit: %s cannot be greater than max limit: %s", r.MinLimit.String(), r.MaxLimit.String())
 	}
 	// maxRequest <= maxLimit
-	if !r.MaxRequest.IsZero() && !r.MaxLimit.IsZero() && r.MaxRequest.Cmp(r.MaxLimit) > 0 {
+	if r.MaxRequest != nil && r.MaxLimit != nil && r.MaxRequest.Cmp(*r.MaxLimit) > 0 {
 		return fmt.Errorf("max request: %s cannot be greater than max limit: %s", r.MaxRequest.String(), r.MaxLimit.String())
 	}
 	// defaultRequest <= maxLimit
-	if !r.DefaultRequest.IsZero() && !r.MaxLimit.IsZero() && r.DefaultRequest.Cmp(r.MaxLimit) > 0 {
+	if r.DefaultRequest != nil && r.MaxLimit != nil && r.DefaultRequest.Cmp(*r.MaxLimit) > 0 {
 		return fmt.Errorf("default request: %s cannot be greater than max limit: %s", r.DefaultRequest.String(), r.MaxLimit.String())
 	}
 	// minRequest <= maxLimit
-	if !r.MinRequest.IsZero() && !r.MaxLimit.IsZero() && r.MinRequest.Cmp(r.MaxLimit) > 0 {
+	if r.MinRequest != nil && r.MaxLimit != nil && r.MinRequest.Cmp(*r.MaxLimit) > 0 {
 		return fmt.Errorf("min request: %s cannot be greater than max limit: %s", r.MinRequest.String(), r.MaxLimit.String())
 	}
 
 	// Validate default limit relationships
 	// minLimit <= defaultLimit
-	if !r.MinLimit.IsZero() && !r.DefaultLimit.IsZero() && r.MinLimit.Cmp(r.DefaultLimit) > 0 {
+	if r.MinLimit != nil && r.DefaultLimit != nil && r.MinLimit.Cmp(*r.DefaultLimit) > 0 {
 		return fmt.Errorf("min limit: %s cannot be greater than default limit: %s", r.MinLimit.String(), r.DefaultLimit.String())
 	}
 	// maxRequest <= defaultLimit
-	if !r.MaxRequest.IsZero() && !r.DefaultLimit.IsZero() && r.MaxRequest.Cmp(r.DefaultLimit) > 0 {
+	if r.MaxRequest != nil && r.DefaultLimit != nil && r.MaxRequest.Cmp(*r.DefaultLimit) > 0 {
 		return fmt.Errorf("max request: %s cannot be greater than default limit: %s", r.MaxRequest.String(), r.DefaultLimit.String())
 	}
 	// defaultRequest <= defaultLimit
-	if !r.DefaultRequest.IsZero() && !r.DefaultLimit.IsZero() && r.DefaultRequest.Cmp(r.DefaultLimit) > 0 {
+	if r.DefaultRequest != nil && r.DefaultLimit != nil && r.DefaultRequest.Cmp(*r.DefaultLimit) > 0 {
 		return fmt.Errorf("default request: %s cannot be greater than default limit: %s", r.DefaultRequest.String(), r.DefaultLimit.String())
 	}
 	// minRequest <= defaultLimit
-	if !r.MinRequest.IsZero() && !r.DefaultLimit.IsZero() && r.MinRequest.Cmp(r.DefaultLimit) > 0 {
+	if r.MinRequest != nil && r.DefaultLimit != nil && r.MinRequest.Cmp(*r.DefaultLimit) > 0 {
 		return fmt.Errorf("min request: %s cannot be greater than default limit: %s", r.MinRequest.String(), r.DefaultLimit.String())
 	}
 
 	// Validate min limit relationships
 	// maxRequest <= minLimit
-	if !r.MaxRequest.IsZero() && !r.MinLimit.IsZero() && r.MaxRequest.Cmp(r.MinLimit) > 0 {
+	if r.MaxRequest != nil && r.MinLimit != nil && r.MaxRequest.Cmp(*r.MinLimit) > 0 {
 		return fmt.Errorf("max request: %s cannot be greater than min limit: %s", r.MaxRequest.String(), r.MinLimit.String())
 	}
 	// defaultRequest <= minLimit
-	if !r.DefaultRequest.IsZero() && !r.MinLimit.IsZero() && r.DefaultRequest.Cmp(r.MinLimit) > 0 {
+	if r.DefaultRequest != nil && r.MinLimit != nil && r.DefaultRequest.Cmp(*r.MinLimit) > 0 {
 		return fmt.Errorf("default request: %s cannot be greater than min limit: %s", r.DefaultRequest.String(), r.MinLimit.String())
 	}
 	// minRequest <= minLimit
-	if !r.MinRequest.IsZero() && !r.MinLimit.IsZero() && r.MinRequest.Cmp(r.MinLimit) > 0 {
+	if r.MinRequest != nil && r.MinLimit != nil && r.MinRequest.Cmp(*r.MinLimit) > 0 {
 		return fmt.Errorf("min request: %s cannot be greater than min limit: %s", r.MinRequest.String(), r.MinLimit.String())
 	}
 
 	// Validate max request relationships
 	// defaultRequest <= maxRequest
-	if !r.DefaultRequest.IsZero() && !r.MaxRequest.IsZero() && r.DefaultRequest.Cmp(r.MaxRequest) > 0 {
+	if r.DefaultRequest != nil && r.MaxRequest != nil && r.DefaultRequest.Cmp(*r.MaxRequest) > 0 {
 		return fmt.Errorf("default request: %s cannot be greater than max request: %s", r.DefaultRequest.String(), r.MaxRequest.String())
 	}
 	// minRequest <= maxRequest
-	if !r.MinRequest.IsZero() && !r.MaxRequest.IsZero() && r.MinRequest.Cmp(r.MaxRequest) > 0 {
+	if r.MinRequest != nil && r.MaxRequest != nil && r.MinRequest.Cmp(*r.MaxRequest) > 0 {
 		return fmt.Errorf("min request: %s cannot be greater than max request: %s", r.MinRequest.String(), r.MaxRequest.String())
 	}
 
 	// Validate default request relationships
 	// minRequest <= defaultRequest
-	if !r.MinRequest.IsZero() && !r.DefaultRequest.IsZero() && r.MinRequest.Cmp(r.DefaultRequest) > 0 {
+	if r.MinRequest != nil && r.DefaultRequest != nil && r.MinRequest.Cmp(*r.DefaultRequest) > 0 {
 		return fmt.Errorf("min request: %s cannot be greater than default request: %s", r.MinRequest.String(), r.DefaultRequest.String())
 	}
 
 	return nil
 }
 
-func (r *ResourceConfiguration) allValuesAreZero() bool {
-	return r.MaxLimit.IsZero() && r.DefaultLimit.IsZero() && r.DefaultRequest.IsZero() && r.MinRequest.IsZero() && r.MinLimit.IsZero() && r.MaxRequest.IsZero()
+func (r *ResourceConfiguration) validLimitPolicy() error {
+	switch r.LimitPolicy {
+	case "", limitPolicyOptional, limitPolicyRequired, limitPolicyForbidden:
+	default:
+		return fmt.Errorf("invalid limit policy '%s'. Valid values are %s, %s and %s", r.LimitPolicy, limitPolicyRequired, limitPolicyOptional, limitPolicyForbidden)
+	}
+	switch r.ForbiddenLimitAction {
+	case "", forbiddenLimitActionReject, forbiddenLimitActionRemove:
+	default:
+		return fmt.Errorf("invalid forbidden limit action '%s'. Valid values are %s and %s", r.ForbiddenLimitAction, forbiddenLimitActionReject, forbiddenLimitActionRemove)
+	}
+	if r.LimitPolicy != limitPolicyForbidden {
+		if r.ForbiddenLimitAction != "" {
+			return errors.New("forbiddenLimitAction requires the forbidden limit policy")
+		}
+		return nil
+	}
+	if r.DefaultLimit != nil || r.MinLimit != nil || r.MaxLimit != nil {
+		return errors.New("defaultLimit, minLimit and maxLimit cannot be defined when the limits are forbidden")
+	}
+	if r.MaxLimitRequestRatio != nil || r.MaxLimitRequestDelta != nil || r.RequireRequestEqualsLimit {
+		return errors.New("maxLimitRequestRatio, maxLimitRequestDelta and requireRequestEqualsLimit cannot be defined when the limits are forbidden")
+	}
+	return nil
+}
+
+// limitForbidden returns true when the limit of the resource is forbidden.
+func (r *ResourceConfiguration) limitForbidden() bool {
+	return r.LimitPolicy == limitPolicyForbidden
+}
+
+// validNonOvercommittable validates the configuration of a resource which
+// cannot be overcommitted, like hugepages and extended resources. These
+// resources are never injected in the containers not using them.
+func (r *ResourceConfiguration) validNonOvercommittable() error {
+	if r == nil {
+		return AllValuesAreZeroError{}
+	}
+	if r.DefaultLimit != nil || r.DefaultRequest != nil || r.IgnoreValues {
+		return errors.New("defaultLimit, defaultRequest and ignoreValues are not supported")
+	}
+	if r.LimitPolicy != "" {
+		return errors.New("limitPolicy is not supported")
+	}
+	if r.Step != nil || r.StepMode != "" {
+		return errors.New("step and stepMode are not supported")
+	}
+	if r.RejectZero {
+		return errors.New("rejectZero is not supported")
+	}
+	if r.OnViolation != "" || r.RepairStrategy != "" {
+		return errors.New("onViolation and repairStrategy are not supported")
+	}
+	if r.DefaultLimitFromRequestRatio != nil || r.DefaultRequestFromLimitRatio != nil {
+		return errors.New("defaultLimitFromRequestRatio and defaultRequestFromLimitRatio are not supported")
+	}
+	return r.valid()
+}
+
+func (r *ResourceConfiguration) withoutPodResourcesDefaults(podResources *corev1.ResourceRequirements, resourceName string) *ResourceConfiguration {
+	if r == nil {
+		return nil
+	}
+	configuration := *r
+	if !missingResourceQuantity(podResources.Limits, resourceName) {
+		configuration.DefaultLimit = nil
+		configuration.DefaultLimitFromRequestRatio = nil
+	}
+	if !missingResourceQuantity(podResources.Requests, resourceName) {
+		configuration.DefaultRequest = nil
+		configuration.DefaultRequestFromLimitRatio = nil
+	}
+	return &configuration
+}
+
+// allValuesAreUnset returns true when none of the rules is configured. The
+// quantities set to an explicit zero are configured.
+func (r *ResourceConfiguration) allValuesAreUnset() bool {
+	return r.MaxLimit == nil && r.DefaultLimit == nil && r.DefaultRequest == nil && r.MinRequest == nil && r.MinLimit == nil && r.MaxRequest == nil &&
+		r.MaxLimitRequestRatio == nil && r.MaxLimitRequestDelta == nil && !r.RequireRequestEqualsLimit && r.LimitPolicy == "" &&
+		r.Step == nil && r.StepMode == "" && !r.RejectZero &&
+		r.DefaultLimitFromRequestRatio == nil && r.DefaultRequestFromLimitRatio == nil
+}
+
+// sectionSettings returns the settings defined by the given section, falling
+// back to the top level settings when the section is not provided. The images
+// ignored at the top level are ignored by the section as well, and the top
+// level unit hygiene, canonicalization and defaulting rules are applied to the
+// section.
+func (s *Settings) sectionSettings(section *Settings) *Settings {
+	if section == nil {
+		return s
+	}
+	settings := *section
+	settings.IgnoreImages = append(append([]string{}, s.IgnoreImages...), section.IgnoreImages...)
+	settings.UnitHygiene = s.UnitHygiene
+	settings.Canonicalize = s.Canonicalize
+	settings.DefaultingMode = s.DefaultingMode
+	return &settings
+}
+
+// initContainerSettings returns the settings used to validate the regular
+// init containers.
+func (s *Settings) initContainerSettings() *Settings {
+	return s.sectionSettings(s.InitContainers)
+}
+
+// sidecarSettings returns the settings used to validate the native sidecar
+// containers.
+func (s *Settings) sidecarSettings() *Settings {
+	return s.sectionSettings(s.Sidecar)
+}
+
+// ephemeralContainerSettings returns the settings used to validate the
+// ephemeral containers, or nil when their validation is not enabled.
+func (s *Settings) ephemeralContainerSettings() *Settings {
+	if s.EphemeralContainers == nil {
+		return nil
+	}
+	return s.sectionSettings(s.EphemeralContainers)
+}
+
+// podSettings returns the settings used to validate the pod-level resources,
+// or nil when their validation is not enabled.
+func (s *Settings) podSettings() *Settings {
+	if s.Pod == nil {
+		return nil
+	}
+	return s.sectionSettings(s.Pod)
+}
+
+// withoutPodResourcesDefaults returns a copy of the settings which does not
+// inject the default limits and requests already defined by the pod-level
+// resources. The containers without a limit or a request are bound by the
+// pod-level values, and injecting the defaults could exceed them.
+func (s *Settings) withoutPodResourcesDefaults(podResources *corev1.ResourceRequirements) *Settings {
+	if !hasPodResources(podResources) {
+		return s
+	}
+	settings := *s
+	settings.Cpu = nil
+	settings.Memory = nil
+	settings.EphemeralStorage = nil
+	settings.Resources = make(map[string]*ResourceConfiguration)
+	for _, resourceSettings := range s.resourceSettings() {
+		settings.Resources[resourceSettings.resourceName] = resourceSettings.configuration.withoutPodResourcesDefaults(podResources, resourceSettings.resourceName)
+	}
+	return &settings
+}
+
+func (s *Settings) hasSections() bool {
+	return s.InitContainers != nil || s.Sidecar != nil || s.EphemeralContainers != nil || s.Pod != nil
+}
+
+func (s *Settings) hasPodTotals() bool {
+	return len(s.MaxPodRequest) > 0 || len(s.MaxPodLimit) > 0
+}
+
+// hasPodRules returns true when the settings define at least one of the rules
+// about the whole Pod, which do not depend on the resource settings.
+func (s *Settings) hasPodRules() bool {
+	return s.QosClass != nil || s.IntegerCpu != nil || s.UnitHygiene != nil || s.Canonicalize != nil
+}
+
+func (s *Settings) hasResources() bool {
+	return len(s.resourceSettings()) > 0
+}
+
+func (s *Settings) hasNonOvercommittableResources() bool {
+	return len(s.Hugepages) > 0 || len(s.ExtendedResources) > 0
+}
+
+// validContainerResources validates the settings of the container resources.
+// The hugepages and extended resources settings can be used without the
+// settings of the other resources.
+func (s *Settings) validContainerResources() error {
+	if s.hasResources() || !s.hasNonOvercommittableResources() {
+		if err := s.validResources(); err != nil {
+			return err
+		}
+	}
+	if err := s.validHugepages(); err != nil {
+		return err
+	}
+	return s.validExtendedResources()
 }
 
 func (s *Settings) Valid() error {
-	if s.Cpu == nil && s.Memory == nil {
-		return fmt.Errorf("no settings provided. At least one resource limit or request must be verified")
+	// The Pod totals, QoS class, integer CPU, unit hygiene and
+	// canonicalization rules can be verified without verifying the containers
+	if s.hasResources() || s.hasNonOvercommittableResources() || (!s.hasPodTotals() && !s.hasPodRules()) {
+		if err := s.validContainerResources(); err != nil {
+			return err
+		}
+	}
+	if err := s.validPodTotals(); err != nil {
+		return err
+	}
+	if s.QosClass != nil {
+		if err := s.QosClass.valid(); err != nil {
+			return errors.Join(errors.New("invalid qosClass settings"), err)
+		}
+	}
+	if err := s.validIntegerCpu(); err != nil {
+		return err
+	}
+	if s.Canonicalize != nil {
+		if err := s.Canonicalize.valid(); err != nil {
+			return errors.Join(errors.New("invalid canonicalize settings"), err)
+		}
+	}
+	switch s.DefaultingMode {
+	case "", defaultingModePolicy, defaultingModeKubernetes:
+	default:
+		return fmt.Errorf("invalid defaulting mode '%s'. Valid values are %s and %s", s.DefaultingMode, defaultingModePolicy, defaultingModeKubernetes)
+	}
+	sections := []struct {
+		name     string
+		settings *Settings
+	}{
+		{"initContainers", s.InitContainers},
+		{"sidecar", s.Sidecar},
+		{"ephemeralContainers", s.EphemeralContainers},
+		{"pod", s.Pod},
+	}
+	for _, section := range sections {
+		if section.settings == nil {
+			continue
+		}
+		if section.settings.hasSections() {
+			return fmt.Errorf("invalid %s settings: nested sections are not supported", section.name)
+		}
+		if section.settings.hasPodTotals() {
+			return fmt.Errorf("invalid %s settings: maxPodRequest and maxPodLimit can be defined only at the top level", section.name)
+		}
+		if section.settings.QosClass != nil || section.settings.IntegerCpu != nil {
+			return fmt.Errorf("invalid %s settings: qosClass and integerCpu can be defined only at the top level", section.name)
+		}
+		if section.settings.UnitHygiene != nil || section.settings.Canonicalize != nil || section.settings.DefaultingMode != "" {
+			return fmt.Errorf("invalid %s settings: unitHygiene, canonicalize and defaultingMode can be defined only at the top level", section.name)
+		}
+		if err := section.settings.validContainerResources(); err != nil {
+			return errors.Join(fmt.Errorf("invalid %s settings", section.name), err)
+		}
+	}
+	if s.EphemeralContainers != nil {
+		if err := s.EphemeralContainers.validEphemeralContainers(); err != nil {
+			return errors.Join(errors.New("invalid ephemeralContainers settings"), err)
+		}
 	}
+	return nil
+}
 
-	var cpuError, memoryError error
-	if s.Cpu != nil {
-		cpuError = s.Cpu.valid()
-		if cpuError != nil {
-			cpuError = errors.Join(fmt.Errorf("invalid cpu settings"), cpuError)
+// validEphemeralContainers validates the ephemeralContainers section.
+// Kubernetes does not allow the ephemeral containers to define resources,
+// therefore the settings injecting the default values, or requiring the
+// limits and requests, would make every debug session fail.
+func (s *Settings) validEphemeralContainers() error {
+	for _, resourceSettings := range s.resourceSettings() {
+		r := resourceSettings.configuration
+		var err error
+		switch {
+		case r.DefaultLimit != nil || r.DefaultRequest != nil || r.IgnoreValues:
+			err = errors.New("defaultLimit, defaultRequest and ignoreValues are not supported, because the ephemeral containers cannot define resources")
+		case r.DefaultLimitFromRequestRatio != nil || r.DefaultRequestFromLimitRatio != nil || r.MirrorRequestAndLimit:
+			err = errors.New("defaultLimitFromRequestRatio, defaultRequestFromLimitRatio and mirrorRequestAndLimit are not supported, because the ephemeral containers cannot define resources")
+		case r.LimitPolicy == limitPolicyRequired:
+			err = errors.New("the required limit policy is not supported, because the ephemeral containers cannot define resources")
 		}
+		if err != nil {
+			return errors.Join(fmt.Errorf("invalid %s settings", resourceSettings.settingsName), err)
+		}
+	}
+	return nil
+}
+
+func (s *Settings) validPodTotals() error {
+	for _, name := range sortedResourceNames(s.MaxPodRequest) {
+		maxPodRequest := s.MaxPodRequest[name]
+		if maxPodRequest.Sign() < 0 {
+			return fmt.Errorf("max pod request: %s for %s cannot be negative", maxPodRequest.String(), name)
+		}
+		if maxPodLimit, found := s.MaxPodLimit[name]; found && maxPodRequest.Cmp(maxPodLimit) > 0 {
+			return fmt.Errorf("max pod request: %s cannot be greater than max pod limit: %s for %s", maxPodRequest.String(), maxPodLimit.String(), name)
+		}
+	}
+	for _, name := range sortedResourceNames(s.MaxPodLimit) {
+		maxPodLimit := s.MaxPodLimit[name]
+		if maxPodLimit.Sign() < 0 {
+			return fmt.Errorf("max pod limit: %s for %s cannot be negative", maxPodLimit.String(), name)
+		}
+	}
+	return nil
+}
+
+// isQualifiedNamePart returns true when the given part of a resource name is
+// made of alphanumeric characters and of the given separators, and it begins
+// and ends with an alphanumeric character.
+func isQualifiedNamePart(part string, maxLength int, separators string, allowUpper bool) bool {
+	if part == "" || len(part) > maxLength {
+		return false
 	}
-	if s.Memory != nil {
-		memoryError = s.Memory.valid()
-		if memoryError != nil {
-			memoryError = errors.Join(fmt.Errorf("invalid memory settings"), memoryError)
+	for i, char := range part {
+		alphanumeric := (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') || (allowUpper && char >= 'A' && char <= 'Z')
+		if alphanumeric {
+			continue
 		}
+		if i == 0 || i == len(part)-1 || !strings.ContainsRune(separators, char) {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidResourceName returns true when the given name is a valid qualified
+// resource name, like `memory` or `nvidia.com/gpu`.
+func isValidResourceName(resourceName string) bool {
+	prefix, name, found := strings.Cut(resourceName, "/")
+	if !found {
+		name = prefix
+	} else if !isQualifiedNamePart(prefix, 253, ".-", false) {
+		return false
 	}
-	if cpuError != nil || memoryError != nil {
-		// user want to validate only one type of resource. The other one should be ignored
-		if (cpuError == nil && errors.Is(memoryError, AllValuesAreZeroError{})) || (memoryError == nil && errors.Is(cpuError, AllValuesAreZeroError{})) {
-			return nil
+	return isQualifiedNamePart(name, 63, "-_.", true)
+}
+
+// isNonOvercommittableResourceName returns true when the given resource
+// cannot be overcommitted, like the hugepages and the extended resources.
+func isNonOvercommittableResourceName(resourceName string) bool {
+	return isHugepagesResourceName(resourceName) || isExtendedResourceName(resourceName)
+}
+
+// validNonOvercommittableResources checks that the hugepages and the extended
+// resources of the Resources map are not configured by the hugepages and the
+// extendedResources settings too.
+func (s *Settings) validNonOvercommittableResources() error {
+	for _, resourceName := range sortedResourceNames(s.Resources) {
+		if isExtendedResourceName(resourceName) {
+			if _, found := s.ExtendedResources[resourceName]; found {
+				return fmt.Errorf("%s settings cannot be defined both in extendedResources and resources", resourceName)
+			}
+			continue
+		}
+		if !isHugepagesResourceName(resourceName) {
+			continue
+		}
+		pageSize, err := hugepagesPageSize(resourceName)
+		if err != nil {
+			return err
+		}
+		for _, hugepagesPageSizeName := range sortedResourceNames(s.Hugepages) {
+			configuredPageSize, err := hugepagesPageSize(hugepagesResourcePrefix + hugepagesPageSizeName)
+			if err == nil && configuredPageSize.Cmp(pageSize) == 0 {
+				return fmt.Errorf("%s settings cannot be defined both in hugepages and resources", resourceName)
+			}
 		}
-		return errors.Join(cpuError, memoryError)
 	}
 	return nil
 }
 
+func (s *Settings) validResources() error {
+	if !s.hasResources() {
+		return fmt.Errorf("no settings provided. At least one resource limit or request must be verified")
+	}
+
+	for _, resourceName := range sortedResourceNames(s.Resources) {
+		if !isValidResourceName(resourceName) {
+			return fmt.Errorf("invalid resource name '%s'", resourceName)
+		}
+	}
+
+	for _, alias := range s.resourceAliases() {
+		if _, found := s.Resources[alias.resourceName]; found && alias.configuration != nil {
+			return fmt.Errorf("%s settings cannot be defined both in %s and resources", alias.resourceName, alias.settingsName)
+		}
+	}
+	if err := s.validNonOvercommittableResources(); err != nil {
+		return err
+	}
+
+	configuredResources := 0
+	allValuesAreZeroErrors := 0
+	var resourceErrors []error
+	for _, resourceSettings := range s.resourceSettings() {
+		configuredResources++
+		valid := resourceSettings.configuration.valid
+		if isNonOvercommittableResourceName(resourceSettings.resourceName) {
+			valid = resourceSettings.configuration.validNonOvercommittable
+		}
+		if err := valid(); err != nil {
+			if errors.Is(err, AllValuesAreZeroError{}) {
+				allValuesAreZeroErrors++
+			}
+			resourceErrors = append(resourceErrors, errors.Join(fmt.Errorf("invalid %s settings", resourceSettings.settingsName), err))
+		}
+	}
+	// user want to validate only some types of resource. The other ones should be ignored
+	if len(resourceErrors) == allValuesAreZeroErrors && (allValuesAreZeroErrors < configuredResources || configuredResources == 1) {
+		return nil
+	}
+	return errors.Join(resourceErrors...)
+}
+
 func NewSettingsFromValidationReq(validationReq *kubewarden_protocol.ValidationRequest) (Settings, error) {
 	settings := Settings{}
 	err := json.Unmarshal(validationReq.Settings, &settings)
@@ -10,19 +10,30 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func checkSettingsValues(t *testing.T, settings *ResourceConfiguration, expectedMaxLimit, expectedDefaultRequest, expectedDefaultLimit string, expectedIgnoreValues bool) {
-	actualMaxLimit := resource.MustParse(expectedMaxLimit)
-	if !settings.MaxLimit.Equal(actualMaxLimit) {
-		t.Errorf("invalid max limit quantity parsed. Expected %+v, got %+v", actualMaxLimit, settings.MaxLimit)
-	}
-	actualDefaultRequest := resource.MustParse(expectedDefaultRequest)
-	if !settings.DefaultRequest.Equal(actualDefaultRequest) {
-		t.Errorf("invalid default request quantity parsed. Expected %+v, got %+v", actualDefaultRequest, settings.DefaultRequest)
+func quantityPtr(value string) *resource.Quantity {
+	quantity := resource.MustParse(value)
+	return &quantity
+}
+
+// checkQuantity checks the parsed quantity. An empty expected value means
+// that the quantity is not configured.
+func checkQuantity(t *testing.T, name string, quantity *resource.Quantity, expected string) {
+	if expected == "" {
+		if quantity != nil {
+			t.Errorf("invalid %s quantity parsed. Expected no value, got %+v", name, quantity)
+		}
+		return
 	}
-	actualDefaultLimit := resource.MustParse(expectedDefaultLimit)
-	if !settings.DefaultLimit.Equal(actualDefaultLimit) {
-		t.Errorf("invalid default limit quantity parsed. Expected %+v, got %+v", actualDefaultLimit, settings.DefaultLimit)
+	expectedQuantity := resource.MustParse(expected)
+	if quantity == nil || !quantity.Equal(expectedQuantity) {
+		t.Errorf("invalid %s quantity parsed. Expected %+v, got %+v", name, expectedQuantity, quantity)
 	}
+}
+
+func checkSettingsValues(t *testing.T, settings *ResourceConfiguration, expectedMaxLimit, expectedDefaultRequest, expectedDefaultLimit string, expectedIgnoreValues bool) {
+	checkQuantity(t, "max limit", settings.MaxLimit, expectedMaxLimit)
+	checkQuantity(t, "default request", settings.DefaultRequest, expectedDefaultRequest)
+	checkQuantity(t, "default limit", settings.DefaultLimit, expectedDefaultLimit)
 	if settings.IgnoreValues != expectedIgnoreValues {
 		t.Errorf("invalid ignoreValues value. Expected %t, got %t", expectedIgnoreValues, settings.IgnoreValues)
 	}
@@ -295,6 +306,455 @@ func TestParsingSettings(t *testing.T) {
 			rawSettings: []byte(`{"cpu": {"minLimit": "2m", "minRequest": "3m", "defaultLimit": "4m", "defaultRequest": "1m"}}`),
 			err:         errors.New("min request: 3m cannot be greater than min limit: 2m"),
 		},
+		{
+			name:        "valid initContainers settings",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2", "defaultRequest": "1", "defaultLimit": "1"}, "initContainers": {"cpu": {"maxLimit": "4", "defaultRequest": "2", "defaultLimit": "2"}}}`),
+		},
+		{
+			name:        "invalid empty initContainers settings",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2", "defaultRequest": "1", "defaultLimit": "1"}, "initContainers": {}}`),
+			err:         errors.New("invalid initContainers settings\nno settings provided. At least one resource limit or request must be verified"),
+		},
+		{
+			name:        "invalid initContainers cpu settings",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2", "defaultRequest": "1", "defaultLimit": "1"}, "initContainers": {"cpu": {"maxLimit": "1", "defaultLimit": "2"}}}`),
+			err:         errors.New("invalid initContainers settings\ninvalid cpu settings\ndefault limit: 2 cannot be greater than max limit: 1"),
+		},
+		{
+			name:        "invalid nested initContainers settings",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "initContainers": {"cpu": {"maxLimit": "1"}, "initContainers": {"cpu": {"maxLimit": "1"}}}}`),
+			err:         errors.New("invalid initContainers settings: nested sections are not supported"),
+		},
+		{
+			name:        "valid sidecar settings",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2", "defaultRequest": "1", "defaultLimit": "1"}, "sidecar": {"cpu": {"maxLimit": "200m", "defaultRequest": "50m", "defaultLimit": "100m"}}}`),
+		},
+		{
+			name:        "invalid sidecar memory settings",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "sidecar": {"memory": {"minRequest": "200Mi", "defaultRequest": "100Mi"}}}`),
+			err:         errors.New("invalid sidecar settings\ninvalid memory settings\nmin request: 200Mi cannot be greater than default request: 100Mi"),
+		},
+		{
+			name:        "invalid initContainers settings inside sidecar settings",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "sidecar": {"cpu": {"maxLimit": "1"}, "initContainers": {"cpu": {"maxLimit": "1"}}}}`),
+			err:         errors.New("invalid sidecar settings: nested sections are not supported"),
+		},
+		{
+			name:        "valid ephemeralContainers settings",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "ephemeralContainers": {"memory": {"minRequest": "64Mi", "maxLimit": "512Mi"}}}`),
+		},
+		{
+			name:        "invalid ephemeralContainers default limit",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "ephemeralContainers": {"memory": {"defaultLimit": "256Mi", "maxLimit": "512Mi"}}}`),
+			err:         errors.New("invalid ephemeralContainers settings\ninvalid memory settings\ndefaultLimit, defaultRequest and ignoreValues are not supported, because the ephemeral containers cannot define resources"),
+		},
+		{
+			name:        "invalid ephemeralContainers ignoreValues",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "ephemeralContainers": {"resources": {"ephemeral-storage": {"ignoreValues": true}}}}`),
+			err:         errors.New("invalid ephemeralContainers settings\ninvalid ephemeral-storage settings\ndefaultLimit, defaultRequest and ignoreValues are not supported, because the ephemeral containers cannot define resources"),
+		},
+		{
+			name:        "invalid ephemeralContainers default limit from request ratio",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "ephemeralContainers": {"cpu": {"defaultLimitFromRequestRatio": "2"}}}`),
+			err:         errors.New("invalid ephemeralContainers settings\ninvalid cpu settings\ndefaultLimitFromRequestRatio, defaultRequestFromLimitRatio and mirrorRequestAndLimit are not supported"),
+		},
+		{
+			name:        "invalid ephemeralContainers required limit policy",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "ephemeralContainers": {"ephemeralStorage": {"limitPolicy": "required", "maxLimit": "1Gi"}}}`),
+			err:         errors.New("invalid ephemeralContainers settings\ninvalid ephemeralStorage settings\nthe required limit policy is not supported"),
+		},
+		{
+			name:        "valid ephemeral storage settings",
+			rawSettings: []byte(`{"ephemeralStorage": {"defaultRequest": "1Gi", "defaultLimit": "2Gi", "maxLimit": "10Gi"}, "checkEmptyDirSizeLimit": true}`),
+		},
+		{
+			name:        "valid ephemeral storage settings with empty memory settings",
+			rawSettings: []byte(`{"memory": {"ignoreValues": false}, "ephemeralStorage": {"maxLimit": "10Gi"}}`),
+		},
+		{
+			name:        "invalid ephemeral storage settings",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "ephemeralStorage": {"defaultLimit": "20Gi", "maxLimit": "10Gi"}}`),
+			err:         errors.New("invalid ephemeralStorage settings\ndefault limit: 20Gi cannot be greater than max limit: 10Gi"),
+		},
+		{
+			name:        "invalid settings with empty cpu, memory and ephemeral storage settings",
+			rawSettings: []byte(`{"cpu": {"ignoreValues": false}, "memory": {"ignoreValues": false}, "ephemeralStorage": {"ignoreValues": false}}`),
+			err:         errors.New("invalid cpu settings\nall the quantities must be defined\ninvalid memory settings\nall the quantities must be defined\ninvalid ephemeralStorage settings\nall the quantities must be defined"),
+		},
+		{
+			name:        "valid hugepages settings",
+			rawSettings: []byte(`{"hugepages": {"2Mi": {"maxLimit": "1Gi"}, "1Gi": {"minLimit": "1Gi", "maxLimit": "8Gi"}}}`),
+		},
+		{
+			name:        "valid hugepages settings inside of a section",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "initContainers": {"hugepages": {"2Mi": {"maxLimit": "1Gi"}}}}`),
+		},
+		{
+			name:        "invalid hugepages page size",
+			rawSettings: []byte(`{"hugepages": {"2Mb": {"maxLimit": "1Gi"}}}`),
+			err:         errors.New("invalid hugepages settings\ninvalid hugepages resource name 'hugepages-2Mb'"),
+		},
+		{
+			name:        "invalid duplicated hugepages page size",
+			rawSettings: []byte(`{"hugepages": {"2Mi": {"maxLimit": "1Gi"}, "2048Ki": {"maxLimit": "2Gi"}}}`),
+			err:         errors.New("invalid hugepages settings: page sizes '2048Ki' and '2Mi' are the same"),
+		},
+		{
+			name:        "invalid hugepages default limit",
+			rawSettings: []byte(`{"hugepages": {"2Mi": {"defaultLimit": "1Gi"}}}`),
+			err:         errors.New("invalid hugepages 2Mi settings\ndefaultLimit, defaultRequest and ignoreValues are not supported"),
+		},
+		{
+			name:        "invalid hugepages max limit",
+			rawSettings: []byte(`{"hugepages": {"1Gi": {"minLimit": "2Gi", "maxLimit": "1Gi"}}}`),
+			err:         errors.New("invalid hugepages 1Gi settings\nmin limit: 2Gi cannot be greater than max limit: 1Gi"),
+		},
+		{
+			name:        "valid extended resources settings",
+			rawSettings: []byte(`{"extendedResources": {"nvidia.com/gpu": {"maxLimit": "2"}, "amd.com/gpu": {"maxLimit": "1"}}, "requireLimitsWithExtendedResources": true}`),
+		},
+		{
+			name:        "invalid extended resource name",
+			rawSettings: []byte(`{"extendedResources": {"gpu": {"maxLimit": "2"}}}`),
+			err:         errors.New("invalid extended resource name 'gpu': the name must be fully qualified, for example 'nvidia.com/gpu'"),
+		},
+		{
+			name:        "invalid extended resource max limit",
+			rawSettings: []byte(`{"extendedResources": {"nvidia.com/gpu": {"maxLimit": "1500m"}}}`),
+			err:         errors.New("invalid nvidia.com/gpu settings: max limit: 1500m must be an integer"),
+		},
+		{
+			name:        "invalid extended resource default limit",
+			rawSettings: []byte(`{"extendedResources": {"nvidia.com/gpu": {"defaultLimit": "1"}}}`),
+			err:         errors.New("invalid nvidia.com/gpu settings\ndefaultLimit, defaultRequest and ignoreValues are not supported"),
+		},
+		{
+			name:        "invalid requireLimitsWithExtendedResources without extended resources",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "requireLimitsWithExtendedResources": true}`),
+			err:         errors.New("requireLimitsWithExtendedResources requires the extendedResources settings"),
+		},
+		{
+			name:        "valid resources settings",
+			rawSettings: []byte(`{"resources": {"cpu": {"maxLimit": "2"}, "memory": {"ignoreValues": true}, "example.com/foo": {"maxLimit": "4"}}}`),
+		},
+		{
+			name:        "valid resources settings with aliases",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "resources": {"memory": {"maxLimit": "1Gi"}}}`),
+		},
+		{
+			name:        "invalid resources settings",
+			rawSettings: []byte(`{"resources": {"ephemeral-storage": {"defaultLimit": "4Gi", "maxLimit": "2Gi"}}}`),
+			err:         errors.New("invalid ephemeral-storage settings\ndefault limit: 4Gi cannot be greater than max limit: 2Gi"),
+		},
+		{
+			name:        "invalid extended resource default limit in resources",
+			rawSettings: []byte(`{"resources": {"example.com/foo": {"defaultLimit": "1", "maxLimit": "2"}}}`),
+			err:         errors.New("invalid example.com/foo settings\ndefaultLimit, defaultRequest and ignoreValues are not supported"),
+		},
+		{
+			name:        "invalid hugepages step in resources",
+			rawSettings: []byte(`{"resources": {"hugepages-2Mi": {"maxLimit": "1Gi", "step": "2Mi"}}}`),
+			err:         errors.New("invalid hugepages-2Mi settings\nstep and stepMode are not supported"),
+		},
+		{
+			name:        "invalid extended resource defined both in extendedResources and resources",
+			rawSettings: []byte(`{"resources": {"nvidia.com/gpu": {"maxLimit": "1"}}, "extendedResources": {"nvidia.com/gpu": {"maxLimit": "2"}}}`),
+			err:         errors.New("nvidia.com/gpu settings cannot be defined both in extendedResources and resources"),
+		},
+		{
+			name:        "invalid hugepages defined both in hugepages and resources",
+			rawSettings: []byte(`{"resources": {"hugepages-2048Ki": {"maxLimit": "1Gi"}}, "hugepages": {"2Mi": {"maxLimit": "2Gi"}}}`),
+			err:         errors.New("hugepages-2048Ki settings cannot be defined both in hugepages and resources"),
+		},
+		{
+			name:        "invalid empty resource name",
+			rawSettings: []byte(`{"resources": {"": {"ignoreValues": true}}}`),
+			err:         errors.New("invalid resource name ''"),
+		},
+		{
+			name:        "invalid resource name",
+			rawSettings: []byte(`{"resources": {"example.com/-foo": {"maxLimit": "2"}}}`),
+			err:         errors.New("invalid resource name 'example.com/-foo'"),
+		},
+		{
+			name:        "invalid extended resource name with an empty prefix",
+			rawSettings: []byte(`{"extendedResources": {"/gpu": {"maxLimit": "2"}}}`),
+			err:         errors.New("invalid extended resource name '/gpu'"),
+		},
+		{
+			name:        "invalid resources settings redefining an alias",
+			rawSettings: []byte(`{"ephemeralStorage": {"maxLimit": "2Gi"}, "resources": {"ephemeral-storage": {"maxLimit": "1Gi"}}}`),
+			err:         errors.New("ephemeral-storage settings cannot be defined both in ephemeralStorage and resources"),
+		},
+		{
+			name:        "valid max limit request ratio and delta",
+			rawSettings: []byte(`{"memory": {"maxLimitRequestRatio": "1.5", "maxLimitRequestDelta": "512Mi"}}`),
+		},
+		{
+			name:        "invalid max limit request ratio",
+			rawSettings: []byte(`{"memory": {"maxLimitRequestRatio": "0.5"}}`),
+			err:         errors.New("invalid memory settings\nmax limit request ratio: 500m cannot be less than 1"),
+		},
+		{
+			name:        "invalid max limit request delta",
+			rawSettings: []byte(`{"memory": {"maxLimitRequestDelta": "-1Gi"}}`),
+			err:         errors.New("invalid memory settings\nmax limit request delta: -1Gi cannot be negative"),
+		},
+		{
+			name:        "valid zero max limit request delta",
+			rawSettings: []byte(`{"memory": {"maxLimitRequestDelta": "0"}}`),
+		},
+		{
+			name:        "invalid zero max limit request ratio",
+			rawSettings: []byte(`{"memory": {"maxLimitRequestRatio": "0"}}`),
+			err:         errors.New("invalid memory settings\nmax limit request ratio: 0 cannot be less than 1"),
+		},
+		{
+			name:        "invalid defaults exceeding the max limit request ratio",
+			rawSettings: []byte(`{"cpu": {"defaultRequest": "100m", "defaultLimit": "500m", "maxLimitRequestRatio": "2"}}`),
+			err:         errors.New("invalid cpu settings\ndefault limit and default request are not valid\nlimit '500m' is more than '2' times the request '100m'"),
+		},
+		{
+			name:        "valid qos class minimum",
+			rawSettings: []byte(`{"qosClass": {"minimum": "Burstable"}}`),
+		},
+		{
+			name:        "valid qos class allowed list",
+			rawSettings: []byte(`{"cpu": {"defaultLimit": "1"}, "qosClass": {"allowed": ["Guaranteed"]}}`),
+		},
+		{
+			name:        "invalid qos class",
+			rawSettings: []byte(`{"qosClass": {"allowed": ["Guaranteed", "Besteffort"]}}`),
+			err:         errors.New("invalid qosClass settings\ninvalid QoS class 'Besteffort'. Valid values are BestEffort, Burstable and Guaranteed"),
+		},
+		{
+			name:        "invalid qos class with allowed list and minimum",
+			rawSettings: []byte(`{"qosClass": {"allowed": ["Guaranteed"], "minimum": "Burstable"}}`),
+			err:         errors.New("invalid qosClass settings\nallowed and minimum cannot be both defined"),
+		},
+		{
+			name:        "invalid empty qos class",
+			rawSettings: []byte(`{"qosClass": {}}`),
+			err:         errors.New("invalid qosClass settings\none of allowed or minimum must be defined"),
+		},
+		{
+			name:        "invalid qos class inside of a section",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "sidecar": {"cpu": {"maxLimit": "1"}, "qosClass": {"minimum": "Burstable"}}}`),
+			err:         errors.New("invalid sidecar settings: qosClass and integerCpu can be defined only at the top level"),
+		},
+		{
+			name:        "valid require request equals limit",
+			rawSettings: []byte(`{"memory": {"requireRequestEqualsLimit": true, "mirrorRequestAndLimit": true}}`),
+		},
+		{
+			name:        "invalid mirror request and limit without require request equals limit",
+			rawSettings: []byte(`{"memory": {"maxLimit": "1Gi", "mirrorRequestAndLimit": true}}`),
+			err:         errors.New("invalid memory settings\nmirrorRequestAndLimit requires requireRequestEqualsLimit"),
+		},
+		{
+			name:        "invalid different defaults with require request equals limit",
+			rawSettings: []byte(`{"memory": {"defaultRequest": "512Mi", "defaultLimit": "1Gi", "requireRequestEqualsLimit": true}}`),
+			err:         errors.New("invalid memory settings\ndefault request: 512Mi must be equal to default limit: 1Gi, because requireRequestEqualsLimit is set"),
+		},
+		{
+			name:        "valid forbidden cpu limits",
+			rawSettings: []byte(`{"cpu": {"defaultRequest": "100m", "maxRequest": "2", "limitPolicy": "forbidden", "forbiddenLimitAction": "remove"}}`),
+		},
+		{
+			name:        "valid required memory limits",
+			rawSettings: []byte(`{"memory": {"maxLimit": "1Gi", "limitPolicy": "required"}}`),
+		},
+		{
+			name:        "invalid limit policy",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2", "limitPolicy": "never"}}`),
+			err:         errors.New("invalid cpu settings\ninvalid limit policy 'never'. Valid values are required, optional and forbidden"),
+		},
+		{
+			name:        "invalid max limit with forbidden limits",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2", "limitPolicy": "forbidden"}}`),
+			err:         errors.New("invalid cpu settings\ndefaultLimit, minLimit and maxLimit cannot be defined when the limits are forbidden"),
+		},
+		{
+			name:        "invalid forbidden limit action without forbidden limits",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2", "forbiddenLimitAction": "remove"}}`),
+			err:         errors.New("invalid cpu settings\nforbiddenLimitAction requires the forbidden limit policy"),
+		},
+		{
+			name:        "valid integer cpu",
+			rawSettings: []byte(`{"integerCpu": {"nodeSelector": {"cpu-manager": "static"}}}`),
+		},
+		{
+			name:        "valid integer cpu with rounded up defaults",
+			rawSettings: []byte(`{"cpu": {"defaultRequest": "500m", "defaultLimit": "1500m", "maxLimit": "2"}, "integerCpu": {"roundUpDefaults": true}}`),
+		},
+		{
+			name:        "invalid integer cpu with fractional defaults",
+			rawSettings: []byte(`{"cpu": {"defaultRequest": "500m", "defaultLimit": "1"}, "integerCpu": {}}`),
+			err:         errors.New("invalid cpu settings: the default values must be whole cores when integerCpu is defined, unless roundUpDefaults is enabled"),
+		},
+		{
+			name:        "invalid integer cpu with rounded up defaults exceeding the max limit",
+			rawSettings: []byte(`{"cpu": {"defaultLimit": "1500m", "maxLimit": "1800m"}, "integerCpu": {"roundUpDefaults": true}}`),
+			err:         errors.New("invalid cpu settings: the default values rounded up to whole cores are not valid\ndefault limit: 2 cannot be greater than max limit: 1800m"),
+		},
+		{
+			name:        "invalid integer cpu with fractional init container defaults",
+			rawSettings: []byte(`{"cpu": {"defaultLimit": "1"}, "initContainers": {"cpu": {"defaultLimit": "100m"}}, "integerCpu": {}}`),
+			err:         errors.New("invalid initContainers cpu settings: the default values must be whole cores when integerCpu is defined, unless roundUpDefaults is enabled"),
+		},
+		{
+			name:        "valid step",
+			rawSettings: []byte(`{"cpu": {"defaultRequest": "100m", "maxLimit": "2", "step": "50m"}, "memory": {"defaultLimit": "512Mi", "step": "64Mi", "stepMode": "roundUp"}}`),
+		},
+		{
+			name:        "invalid default value not multiple of the step",
+			rawSettings: []byte(`{"memory": {"defaultRequest": "100Mi", "step": "64Mi"}}`),
+			err:         errors.New("invalid memory settings\ndefault request: 100Mi is not a multiple of the step: 64Mi"),
+		},
+		{
+			name:        "invalid negative step",
+			rawSettings: []byte(`{"cpu": {"step": "-50m"}}`),
+			err:         errors.New("invalid cpu settings\nstep: -50m must be greater than 0"),
+		},
+		{
+			name:        "invalid zero step",
+			rawSettings: []byte(`{"cpu": {"step": "0"}}`),
+			err:         errors.New("invalid cpu settings\nstep: 0 must be greater than 0"),
+		},
+		{
+			name:        "invalid step mode",
+			rawSettings: []byte(`{"cpu": {"step": "50m", "stepMode": "roundDown"}}`),
+			err:         errors.New("invalid cpu settings\ninvalid step mode 'roundDown'. Valid values are reject and roundUp"),
+		},
+		{
+			name:        "invalid step mode without step",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2", "stepMode": "roundUp"}}`),
+			err:         errors.New("invalid cpu settings\nstepMode requires the step"),
+		},
+		{
+			name:        "valid unit hygiene",
+			rawSettings: []byte(`{"unitHygiene": {"rejectDecimalMemory": true}}`),
+		},
+		{
+			name:        "invalid unit hygiene in a section",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "initContainers": {"cpu": {"maxLimit": "1"}, "unitHygiene": {}}}`),
+			err:         errors.New("invalid initContainers settings: unitHygiene, canonicalize and defaultingMode can be defined only at the top level"),
+		},
+		{
+			name:        "valid canonicalize",
+			rawSettings: []byte(`{"canonicalize": {"preferredUnits": {"cpu": "m", "memory": "Mi"}}}`),
+		},
+		{
+			name:        "invalid canonicalize preferred unit",
+			rawSettings: []byte(`{"canonicalize": {"preferredUnits": {"memory": "MB"}}}`),
+			err:         errors.New("invalid canonicalize settings\ninvalid memory preferred unit: invalid unit 'MB'"),
+		},
+		{
+			name:        "valid explicit zero min request",
+			rawSettings: []byte(`{"cpu": {"minRequest": "0"}}`),
+		},
+		{
+			name:        "valid forbidden resource",
+			rawSettings: []byte(`{"ephemeralStorage": {"maxLimit": "0", "maxRequest": "0"}}`),
+		},
+		{
+			name:        "invalid default limit greater than the explicit zero max limit",
+			rawSettings: []byte(`{"memory": {"maxLimit": "0", "defaultLimit": "1Gi"}}`),
+			err:         errors.New("invalid memory settings\ndefault limit: 1Gi cannot be greater than max limit: 0"),
+		},
+		{
+			name:        "valid reject zero",
+			rawSettings: []byte(`{"cpu": {"rejectZero": true}, "memory": {"ignoreValues": true, "rejectZero": true}}`),
+		},
+		{
+			name:        "invalid zero default request with reject zero",
+			rawSettings: []byte(`{"cpu": {"defaultRequest": "0", "rejectZero": true}}`),
+			err:         errors.New("invalid cpu settings\ndefault request and default limit cannot be zero when rejectZero is set"),
+		},
+		{
+			name:        "valid kubernetes defaulting mode",
+			rawSettings: []byte(`{"cpu": {"defaultRequest": "100m"}, "defaultingMode": "kubernetes"}`),
+		},
+		{
+			name:        "invalid defaulting mode",
+			rawSettings: []byte(`{"cpu": {"defaultRequest": "100m"}, "defaultingMode": "scheduler"}`),
+			err:         errors.New("invalid defaulting mode 'scheduler'. Valid values are policy and kubernetes"),
+		},
+		{
+			name:        "valid clamp on violation",
+			rawSettings: []byte(`{"cpu": {"maxRequest": "500m", "minLimit": "1", "onViolation": "clamp"}}`),
+		},
+		{
+			name:        "invalid on violation",
+			rawSettings: []byte(`{"cpu": {"maxRequest": "1", "onViolation": "ignore"}}`),
+			err:         errors.New("invalid cpu settings\ninvalid onViolation value 'ignore'. Valid values are reject and clamp"),
+		},
+		{
+			name:        "valid repair strategy",
+			rawSettings: []byte(`{"cpu": {"defaultLimit": "500m", "maxLimit": "2", "repairStrategy": "raiseLimit"}}`),
+		},
+		{
+			name:        "invalid repair strategy",
+			rawSettings: []byte(`{"cpu": {"defaultLimit": "500m", "repairStrategy": "dropLimit"}}`),
+			err:         errors.New("invalid cpu settings\ninvalid repair strategy 'dropLimit'. Valid values are raiseLimit and lowerRequest"),
+		},
+		{
+			name:        "valid ratio defaults",
+			rawSettings: []byte(`{"cpu": {"defaultLimitFromRequestRatio": 2, "defaultRequestFromLimitRatio": 0.5, "maxLimitRequestRatio": 4}}`),
+		},
+		{
+			name:        "invalid default limit from request ratio",
+			rawSettings: []byte(`{"cpu": {"defaultLimitFromRequestRatio": 0.5}}`),
+			err:         errors.New("invalid cpu settings\ndefault limit from request ratio: 500m cannot be less than 1"),
+		},
+		{
+			name:        "default limit from request ratio greater than max limit request ratio",
+			rawSettings: []byte(`{"cpu": {"defaultLimitFromRequestRatio": 3, "maxLimitRequestRatio": 2}}`),
+			err:         errors.New("invalid cpu settings\ndefault limit from request ratio: 3 cannot be greater than max limit request ratio: 2"),
+		},
+		{
+			name:        "invalid default request from limit ratio",
+			rawSettings: []byte(`{"memory": {"defaultRequestFromLimitRatio": 2}}`),
+			err:         errors.New("invalid memory settings\ndefault request from limit ratio: 2 must be greater than 0 and not greater than 1"),
+		},
+		{
+			name:        "invalid zero default request from limit ratio",
+			rawSettings: []byte(`{"memory": {"defaultRequestFromLimitRatio": 0}}`),
+			err:         errors.New("invalid memory settings\ndefault request from limit ratio: 0 must be greater than 0 and not greater than 1"),
+		},
+		{
+			name:        "invalid zero default limit from request ratio",
+			rawSettings: []byte(`{"memory": {"defaultLimitFromRequestRatio": 0}}`),
+			err:         errors.New("invalid memory settings\ndefault limit from request ratio: 0 cannot be less than 1"),
+		},
+		{
+			name:        "ratio defaults with forbidden limits",
+			rawSettings: []byte(`{"cpu": {"defaultRequestFromLimitRatio": 0.5, "limitPolicy": "forbidden"}}`),
+			err:         errors.New("defaultLimitFromRequestRatio and defaultRequestFromLimitRatio cannot be defined when the limits are forbidden"),
+		},
+		{
+			name:        "ratio defaults with ignore values",
+			rawSettings: []byte(`{"cpu": {"defaultLimitFromRequestRatio": 2, "ignoreValues": true}}`),
+			err:         errors.New("defaultLimitFromRequestRatio and defaultRequestFromLimitRatio cannot be defined when ignoreValues is set"),
+		},
+		{
+			name:        "valid max pod request and limit",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "maxPodRequest": {"cpu": "4", "memory": "8Gi"}, "maxPodLimit": {"cpu": "8"}}`),
+		},
+		{
+			name:        "valid max pod limit only",
+			rawSettings: []byte(`{"maxPodLimit": {"memory": "8Gi"}}`),
+		},
+		{
+			name:        "invalid max pod request greater than max pod limit",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "maxPodRequest": {"cpu": "4"}, "maxPodLimit": {"cpu": "2"}}`),
+			err:         errors.New("max pod request: 4 cannot be greater than max pod limit: 2 for cpu"),
+		},
+		{
+			name:        "invalid max pod limit inside of a section",
+			rawSettings: []byte(`{"cpu": {"maxLimit": "2"}, "initContainers": {"cpu": {"maxLimit": "1"}, "maxPodLimit": {"cpu": "2"}}}`),
+			err:         errors.New("invalid initContainers settings: maxPodRequest and maxPodLimit can be defined only at the top level"),
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -391,7 +851,27 @@ func TestNewSettingsPartialFieldsOnlyFromValidationReq(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Unexpected error %+v", err)
 		}
-		checkSettingsValues(t, settings.Cpu, "0", "0", "0", true)
-		checkSettingsValues(t, settings.Memory, "0", "0", "0", true)
+		checkSettingsValues(t, settings.Cpu, "", "", "", true)
+		checkSettingsValues(t, settings.Memory, "", "", "", true)
 	})
 }
+
+func TestResourceSettings(t *testing.T) {
+	settings := Settings{
+		Memory: &ResourceConfiguration{IgnoreValues: true},
+		Resources: map[string]*ResourceConfiguration{
+			"example.com/foo":   {MaxLimit: quantityPtr("4")},
+			"ephemeral-storage": {MaxLimit: quantityPtr("1Gi")},
+			"cpu":               {IgnoreValues: true},
+		},
+	}
+
+	resourceNames := []string{}
+	for _, resourceSettings := range settings.resourceSettings() {
+		resourceNames = append(resourceNames, resourceSettings.resourceName)
+	}
+	require.Equal(t, []string{"cpu", "memory", "ephemeral-storage", "example.com/foo"}, resourceNames)
+	require.Equal(t, []string{"cpu", "memory"}, settings.requiredResources())
+	require.Same(t, settings.Memory, settings.resourceConfiguration("memory"))
+	require.Nil(t, settings.resourceConfiguration("hugepages-2Mi"))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+const (
+	stepModeReject  = "reject"
+	stepModeRoundUp = "roundUp"
+)
+
+// isMultipleOfStep returns true when the quantity is a multiple of the step.
+func isMultipleOfStep(quantity, step resource.Quantity) bool {
+	ratio, exact := quantity.Div(step)
+	return ratio.RoundUp(0) && exact
+}
+
+// roundUpToStep rounds the quantity up to the next multiple of the step.
+func roundUpToStep(quantity, step resource.Quantity) resource.Quantity {
+	ratio, exact := quantity.Div(step)
+	if ratio.RoundUp(0) && exact {
+		return quantity
+	}
+	rounded := step.DeepCopy()
+	rounded.Mul(ratio.Value())
+	rounded.Format = quantity.Format
+	return rounded
+}
+
+func (r *ResourceConfiguration) validStep() error {
+	switch r.StepMode {
+	case "", stepModeReject, stepModeRoundUp:
+	default:
+		return fmt.Errorf("invalid step mode '%s'. Valid values are %s and %s", r.StepMode, stepModeReject, stepModeRoundUp)
+	}
+	if r.Step == nil {
+		if r.StepMode != "" {
+			return errors.New("stepMode requires the step")
+		}
+		return nil
+	}
+	if r.Step.Sign() <= 0 {
+		return fmt.Errorf("step: %s must be greater than 0", r.Step.String())
+	}
+	quantities := []struct {
+		name     string
+		quantity *resource.Quantity
+	}{
+		{"min limit", r.MinLimit},
+		{"max limit", r.MaxLimit},
+		{"min request", r.MinRequest},
+		{"max request", r.MaxRequest},
+		{"default request", r.DefaultRequest},
+		{"default limit", r.DefaultLimit},
+	}
+	for _, quantity := range quantities {
+		if quantity.quantity != nil && !isMultipleOfStep(*quantity.quantity, *r.Step) {
+			return fmt.Errorf("%s: %s is not a multiple of the step: %s", quantity.name, quantity.quantity.String(), r.Step.String())
+		}
+	}
+	return nil
+}
+
+// adjustResourceStep validates that the container limit and request of the
+// given resource are multiples of the configured step. When the step mode is
+// roundUp, the values are rounded up to the next multiple of the step
+// instead. Returns true when the container has been mutated.
+func adjustResourceStep(container *corev1.Container, resourceName string, resourceConfig *ResourceConfiguration) (bool, error) {
+	if resourceConfig.Step == nil {
+		return false, nil
+	}
+	mutated := false
+	resourceQuantities := []struct {
+		resourceType string
+		quantities   map[string]*api_resource.Quantity
+	}{
+		{"limit", container.Resources.Limits},
+		{"request", container.Resources.Requests},
+	}
+	for _, resourceQuantity := range resourceQuantities {
+		if missingResourceQuantity(resourceQuantity.quantities, resourceName) {
+			continue
+		}
+		quantity, err := parseResourceQuantity(resourceQuantity.quantities, resourceName, resourceQuantity.resourceType)
+		if err != nil {
+			return false, err
+		}
+		if isMultipleOfStep(quantity, *resourceConfig.Step) {
+			continue
+		}
+		if resourceConfig.StepMode != stepModeRoundUp {
+			return false, fmt.Errorf("%s %s '%s' is not a multiple of the step '%s'", resourceName, resourceQuantity.resourceType, quantity.String(), resourceConfig.Step.String())
+		}
+		rounded := roundUpToStep(quantity, *resourceConfig.Step)
+		newQuantity := api_resource.Quantity(rounded.String())
+		resourceQuantity.quantities[resourceName] = &newQuantity
+		mutated = true
+	}
+	return mutated, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/kubewarden/container-resources-policy/resource"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+// UnitHygieneConfiguration rejects the quantities which are accepted by
+// Kubernetes, but whose unit is almost certainly a mistake, like the memory
+// in millibytes (`100m`) or the cpu with a binary suffix (`2Gi`).
+type UnitHygieneConfiguration struct {
+	// RejectDecimalMemory rejects the memory quantities using a decimal
+	// suffix, like `1G`, instead of a binary one, like `1Gi`.
+	RejectDecimalMemory bool `json:"rejectDecimalMemory,omitempty"`
+}
+
+// isByteResourceName returns true when the quantities of the given resource
+// are expressed in bytes.
+func isByteResourceName(resourceName string) bool {
+	return resourceName == "memory" || resourceName == "ephemeral-storage" || isHugepagesResourceName(resourceName)
+}
+
+// isWholeNumberOfBytes returns true when the quantity has no fractional part.
+// Unlike isIntegerQuantity, the check is exact for any scale of the quantity.
+func isWholeNumberOfBytes(quantity resource.Quantity) bool {
+	rounded := quantity.DeepCopy()
+	return rounded.RoundUp(0)
+}
+
+// hasSuffix returns true when the raw quantity ends with a suffix, like `M`
+// or `Mi`. The quantities in the exponent notation, like `1e9`, do not have a
+// suffix.
+func hasSuffix(rawQuantity string) bool {
+	rawQuantity = strings.TrimSpace(rawQuantity)
+	if rawQuantity == "" {
+		return false
+	}
+	return unicode.IsLetter(rune(rawQuantity[len(rawQuantity)-1]))
+}
+
+// check returns the reason why the given quantity of the resource is not
+// valid, or an empty string when the quantity is valid.
+func (u *UnitHygieneConfiguration) check(resourceName string, rawQuantity string, quantity resource.Quantity) string {
+	switch {
+	case isByteResourceName(resourceName) && !isWholeNumberOfBytes(quantity):
+		return "is not a whole number of bytes"
+	case resourceName == "cpu" && quantity.Format == resource.BinarySI:
+		return "uses a binary suffix, which is not valid for cpu"
+	case resourceName == "memory" && u.RejectDecimalMemory && quantity.Format == resource.DecimalSI && hasSuffix(rawQuantity):
+		return "uses a decimal suffix, a binary suffix like Mi or Gi is required"
+	}
+	return ""
+}
+
+// validateUnitHygiene validates the units of the limits and requests of the
+// given resources. The name identifies the resources in the error messages.
+func validateUnitHygiene(resources *corev1.ResourceRequirements, name string, unitHygiene *UnitHygieneConfiguration) error {
+	if unitHygiene == nil || resources == nil {
+		return nil
+	}
+	resourceQuantities := []struct {
+		resourceType string
+		quantities   map[string]*api_resource.Quantity
+	}{
+		{"limit", resources.Limits},
+		{"request", resources.Requests},
+	}
+	for _, resourceQuantity := range resourceQuantities {
+		for _, resourceName := range sortedResourceNames(resourceQuantity.quantities) {
+			if missingResourceQuantity(resourceQuantity.quantities, resourceName) {
+				continue
+			}
+			quantity, err := parseResourceQuantity(resourceQuantity.quantities, resourceName, resourceQuantity.resourceType)
+			if err != nil {
+				return err
+			}
+			rawQuantity := string(*resourceQuantity.quantities[resourceName])
+			if reason := unitHygiene.check(resourceName, rawQuantity, quantity); reason != "" {
+				return fmt.Errorf("%s %s %s '%s' %s", name, resourceName, resourceQuantity.resourceType, rawQuantity, reason)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	apimachinery_pkg_api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+)
+
+func TestUnitHygiene(t *testing.T) {
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	binaryCpu := apimachinery_pkg_api_resource.Quantity("2Gi")
+	oneGi := apimachinery_pkg_api_resource.Quantity("1Gi")
+	oneG := apimachinery_pkg_api_resource.Quantity("1G")
+	plainBytes := apimachinery_pkg_api_resource.Quantity("1000000000")
+	milliMemory := apimachinery_pkg_api_resource.Quantity("100m")
+	nanoMemory := apimachinery_pkg_api_resource.Quantity("1999999999n")
+	largeStorage := apimachinery_pkg_api_resource.Quantity("10Pi")
+	empty := apimachinery_pkg_api_resource.Quantity("")
+	appName := "app"
+	unitHygiene := &UnitHygieneConfiguration{}
+
+	tests := []struct {
+		name             string
+		resources        *corev1.ResourceRequirements
+		podResources     *corev1.ResourceRequirements
+		settings         Settings
+		expectedErrorMsg string
+	}{
+		{
+			"valid units",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore, "memory": &oneG},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			nil,
+			Settings{UnitHygiene: unitHygiene},
+			"",
+		},
+		{
+			"null and empty quantities skipped",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &empty, "memory": nil},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			nil,
+			Settings{UnitHygiene: unitHygiene},
+			"",
+		},
+		{
+			"memory in millibytes",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &milliMemory},
+			},
+			nil,
+			Settings{UnitHygiene: unitHygiene},
+			"container 'app' memory request '100m' is not a whole number of bytes",
+		},
+		{
+			"memory in nanobytes",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &nanoMemory},
+			},
+			nil,
+			Settings{UnitHygiene: unitHygiene},
+			"container 'app' memory request '1999999999n' is not a whole number of bytes",
+		},
+		{
+			"large ephemeral storage",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"ephemeral-storage": &largeStorage},
+			},
+			nil,
+			Settings{UnitHygiene: unitHygiene},
+			"",
+		},
+		{
+			"ephemeral storage in millibytes",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"ephemeral-storage": &milliMemory},
+			},
+			nil,
+			Settings{UnitHygiene: unitHygiene},
+			"container 'app' ephemeral-storage limit '100m' is not a whole number of bytes",
+		},
+		{
+			"cpu with binary suffix",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &binaryCpu},
+			},
+			nil,
+			Settings{UnitHygiene: unitHygiene},
+			"container 'app' cpu limit '2Gi' uses a binary suffix, which is not valid for cpu",
+		},
+		{
+			"memory with decimal suffix rejected",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneG},
+			},
+			nil,
+			Settings{UnitHygiene: &UnitHygieneConfiguration{RejectDecimalMemory: true}},
+			"container 'app' memory limit '1G' uses a decimal suffix, a binary suffix like Mi or Gi is required",
+		},
+		{
+			"memory without suffix allowed",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &plainBytes},
+			},
+			nil,
+			Settings{UnitHygiene: &UnitHygieneConfiguration{RejectDecimalMemory: true}},
+			"",
+		},
+		{
+			"pod-level resources",
+			nil,
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &milliMemory},
+			},
+			Settings{UnitHygiene: unitHygiene},
+			"the pod-level resources memory limit '100m' is not a whole number of bytes",
+		},
+		{
+			"values checked before injecting the defaults",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &milliMemory},
+			},
+			nil,
+			Settings{
+				Memory: &ResourceConfiguration{
+					DefaultRequest: quantityPtr("128Mi"),
+				},
+				UnitHygiene: unitHygiene,
+			},
+			"container 'app' memory limit '100m' is not a whole number of bytes",
+		},
+		{
+			"ignored image",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &binaryCpu},
+			},
+			nil,
+			Settings{UnitHygiene: unitHygiene, IgnoreImages: []string{"image:latest"}},
+			"",
+		},
+		{
+			"disabled",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &binaryCpu},
+			},
+			nil,
+			Settings{IgnoreImages: []string{"other:latest"}},
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Name: &appName, Image: "image:latest", Resources: test.resources}},
+			}
+			_, err := validatePodSpec(&podSpec, test.podResources, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+		})
+	}
+}
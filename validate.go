@@ -13,50 +13,101 @@ import (
 	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
 )
 
+// containerRestartPolicyAlways is the restart policy identifying the native
+// sidecar containers among the init containers.
+const containerRestartPolicyAlways = "Always"
+
+// emptyDirMediumMemory is the medium of the emptyDir volumes backed by memory
+// (tmpfs).
+const emptyDirMediumMemory = "Memory"
+
+// ephemeralContainersSubResource is the Pod subresource used to add ephemeral
+// containers to a running Pod, for example by `kubectl debug`.
+const ephemeralContainersSubResource = "ephemeralcontainers"
+
 func missingResourceQuantity(resources map[string]*api_resource.Quantity, resourceName string) bool {
 	resourceStr, found := resources[resourceName]
 	return !found || resourceStr == nil || len(strings.TrimSpace(string(*resourceStr))) == 0
 }
 
-func adjustResourceRequest(container *corev1.Container, resourceName string, resourceConfig *ResourceConfiguration) bool {
-	if missingResourceQuantity(container.Resources.Requests, resourceName) &&
-		!resourceConfig.DefaultRequest.IsZero() {
-		newRequest := api_resource.Quantity(resourceConfig.DefaultRequest.String())
-		container.Resources.Requests[resourceName] = &newRequest
-		return true
+// adjustResourceRequest injects the default request of the given resource
+// into the container missing it. The requests derived from the limit must
+// fall in the configured range. limitInjected reports whether the limit has
+// been injected by the policy. Returns true when the container has been
+// mutated.
+func adjustResourceRequest(container *corev1.Container, resourceName string, resourceConfig *ResourceConfiguration, limitInjected bool) (bool, error) {
+	if !missingResourceQuantity(container.Resources.Requests, resourceName) {
+		return false, nil
 	}
-	return false
+	defaultRequest, derived, err := resourceConfig.defaultRequest(container, resourceName, limitInjected)
+	if err != nil || defaultRequest == nil {
+		return false, err
+	}
+	newRequest := api_resource.Quantity(defaultRequest.String())
+	container.Resources.Requests[resourceName] = &newRequest
+	if derived {
+		if resourceConfig.MinRequest != nil {
+			if _, err := enforceResourceMin(container.Resources.Requests, resourceName, *resourceConfig.MinRequest, "request", resourceConfig.OnViolation); err != nil {
+				return false, err
+			}
+		}
+		if resourceConfig.MaxRequest != nil {
+			if _, err := enforceResourceMax(container.Resources.Requests, resourceName, *resourceConfig.MaxRequest, "request", resourceConfig.OnViolation); err != nil {
+				return false, err
+			}
+		}
+	}
+	return true, nil
 }
 
-func validateContainerCheckPresenceLimits(container *corev1.Container, settings *Settings) error {
-	if container.Resources.Limits == nil && settings.shouldIgnoreCpuValues() && settings.shouldIgnoreMemoryValues() {
-		return fmt.Errorf("container does not have any resource limits")
+// withIndefiniteArticle returns the given resource name preceded by its
+// indefinite article, for example `an ephemeral-storage`.
+func withIndefiniteArticle(resourceName string) string {
+	if resourceName != "" && strings.ContainsAny(resourceName[:1], "aeiou") {
+		return "an " + resourceName
 	}
+	return "a " + resourceName
+}
 
-	if settings.shouldIgnoreCpuValues() && missingResourceQuantity(container.Resources.Limits, "cpu") {
-		return fmt.Errorf("container does not have a cpu limit")
+// missingOrRejectedZeroQuantity returns true when the quantity of the given
+// resource is missing, or when it is zero and the zero values of the resource
+// are rejected.
+func missingOrRejectedZeroQuantity(resources map[string]*api_resource.Quantity, resourceName string, settings *Settings) bool {
+	return missingResourceQuantity(resources, resourceName) ||
+		(settings.resourceConfiguration(resourceName).RejectZero && isZeroResourceQuantity(resources, resourceName))
+}
+
+func validateContainerCheckPresenceLimits(container *corev1.Container, settings *Settings) error {
+	requiredResources := []string{}
+	for _, resourceName := range settings.requiredResources() {
+		// The forbidden limits are checked later
+		if !settings.resourceConfiguration(resourceName).limitForbidden() {
+			requiredResources = append(requiredResources, resourceName)
+		}
+	}
+	if container.Resources.Limits == nil && len(requiredResources) > 1 {
+		return fmt.Errorf("container does not have any resource limits")
 	}
 
-	if settings.shouldIgnoreMemoryValues() && missingResourceQuantity(container.Resources.Limits, "memory") {
-		return fmt.Errorf("container does not have a memory limit")
+	for _, resourceName := range requiredResources {
+		if missingOrRejectedZeroQuantity(container.Resources.Limits, resourceName, settings) {
+			return fmt.Errorf("container does not have %s limit", withIndefiniteArticle(resourceName))
+		}
 	}
 
 	return nil
 }
 
 func validateContainerCheckPresenceRequests(container *corev1.Container, settings *Settings) error {
-	if container.Resources.Requests == nil && settings.shouldIgnoreCpuValues() && settings.shouldIgnoreMemoryValues() {
+	requiredResources := settings.requiredResources()
+	if container.Resources.Requests == nil && len(requiredResources) > 1 {
 		return fmt.Errorf("container does not have any resource requests")
 	}
 
-	_, found := container.Resources.Requests["cpu"]
-	if !found && settings.shouldIgnoreCpuValues() {
-		return fmt.Errorf("container does not have a cpu request")
-	}
-
-	_, found = container.Resources.Requests["memory"]
-	if !found && settings.shouldIgnoreMemoryValues() {
-		return fmt.Errorf("container does not have a memory request")
+	for _, resourceName := range requiredResources {
+		if _, found := container.Resources.Requests[resourceName]; !found || (settings.resourceConfiguration(resourceName).RejectZero && isZeroResourceQuantity(container.Resources.Requests, resourceName)) {
+			return fmt.Errorf("container does not have %s request", withIndefiniteArticle(resourceName))
+		}
 	}
 
 	return nil
@@ -67,9 +118,11 @@ func validateContainerCheckPresenceRequests(container *corev1.Container, setting
 // Returns an error if the limits/requests are not set and IgnoreValues is set
 // to true, nil otherwise.
 func validateContainerCheckPresence(container *corev1.Container, settings *Settings) error {
-	if container.Resources == nil && (settings.shouldIgnoreCpuValues() || settings.shouldIgnoreMemoryValues()) {
-		missing := fmt.Sprintf("required Cpu:%t, Memory:%t", settings.shouldIgnoreCpuValues(), settings.shouldIgnoreMemoryValues())
-		return fmt.Errorf("container does not have any resource limits or requests: %s", missing)
+	if container.Resources == nil {
+		if requiredResources := settings.requiredResources(); len(requiredResources) > 0 {
+			return fmt.Errorf("container does not have any resource limits or requests: required %s", strings.Join(requiredResources, ", "))
+		}
+		return nil
 	}
 	if err := validateContainerCheckPresenceLimits(container, settings); err != nil {
 		return err
@@ -85,19 +138,25 @@ func validateContainerCheckPresence(container *corev1.Container, settings *Setti
 //
 // When the CPU/Memory request is specified: no action or check is done against it.
 // When the CPU/Memory request is not specified: the policy mutates the
-// container and adds the `defaultRequest` value. The policy does
-// not check the consistency of the applied value.
+// container and adds the `defaultRequest` value, or the request derived from
+// the limit. The policy does not check the consistency of the applied value,
+// except for the derived requests, which must fall in the configured range.
+// missingLimits holds the resources whose limit was missing before injecting
+// the default limits.
 //
 // Returns `true` when the container has been mutated
-func validateAndAdjustContainerResourceRequests(container *corev1.Container, settings *Settings) bool {
+func validateAndAdjustContainerResourceRequests(container *corev1.Container, settings *Settings, missingLimits map[string]bool) (bool, error) {
 	mutated := false
-	if settings.Memory != nil {
-		mutated = adjustResourceRequest(container, "memory", settings.Memory)
-	}
-	if settings.Cpu != nil {
-		mutated = adjustResourceRequest(container, "cpu", settings.Cpu) || mutated
+	for _, resourceSettings := range settings.resourceSettings() {
+		resourceName := resourceSettings.resourceName
+		limitInjected := missingLimits[resourceName] && !missingResourceQuantity(container.Resources.Limits, resourceName)
+		resourceMutated, err := adjustResourceRequest(container, resourceName, resourceSettings.configuration, limitInjected)
+		if err != nil {
+			return false, err
+		}
+		mutated = resourceMutated || mutated
 	}
-	return mutated
+	return mutated, nil
 }
 
 // Ensure that the limit is greater than or equal to the request
@@ -120,6 +179,199 @@ func isResourceLimitGreaterThanRequest(container *corev1.Container, resourceName
 	return nil
 }
 
+// repairInjectedDefault repairs the limit of the given resource lower than its
+// request, when it is caused by an injected default value and the
+// resourceConfig allows so. The raiseLimit strategy raises the injected limit
+// to the request, capped at the max limit, while the lowerRequest strategy
+// lowers the injected request to the limit. Returns true when the container
+// has been mutated.
+func repairInjectedDefault(container *corev1.Container, resourceName string, resourceConfig *ResourceConfiguration, limitInjected, requestInjected bool) (bool, error) {
+	repairLimit := resourceConfig.RepairStrategy == repairStrategyRaiseLimit && limitInjected
+	repairRequest := resourceConfig.RepairStrategy == repairStrategyLowerRequest && requestInjected
+	if !repairLimit && !repairRequest {
+		return false, nil
+	}
+	limit, err := parseResourceQuantity(container.Resources.Limits, resourceName, "limit")
+	if err != nil {
+		return false, err
+	}
+	request, err := parseResourceQuantity(container.Resources.Requests, resourceName, "request")
+	if err != nil {
+		return false, err
+	}
+	if limit.Cmp(request) >= 0 {
+		return false, nil
+	}
+	if repairLimit {
+		repairedLimit := request
+		if resourceConfig.MaxLimit != nil && repairedLimit.Cmp(*resourceConfig.MaxLimit) > 0 {
+			repairedLimit = *resourceConfig.MaxLimit
+		}
+		newLimit := api_resource.Quantity(repairedLimit.String())
+		container.Resources.Limits[resourceName] = &newLimit
+		return true, nil
+	}
+	newRequest := api_resource.Quantity(limit.String())
+	container.Resources.Requests[resourceName] = &newRequest
+	if resourceConfig.MinRequest != nil {
+		if err := validateResourceMin(container.Resources.Requests, resourceName, *resourceConfig.MinRequest, "request"); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// adjustForbiddenLimit rejects the forbidden limit of the given resource, or
+// removes it when the resourceConfig allows so. Returns true when the
+// container has been mutated.
+func adjustForbiddenLimit(container *corev1.Container, resourceName string, resourceConfig *ResourceConfiguration) (bool, error) {
+	if missingResourceQuantity(container.Resources.Limits, resourceName) {
+		return false, nil
+	}
+	if resourceConfig.ForbiddenLimitAction != forbiddenLimitActionRemove {
+		return false, fmt.Errorf("container has %s limit, but the %s limits are forbidden", withIndefiniteArticle(resourceName), resourceName)
+	}
+	delete(container.Resources.Limits, resourceName)
+	return true, nil
+}
+
+// isZeroResourceQuantity returns true when the quantity of the given resource
+// is defined and equal to zero.
+func isZeroResourceQuantity(resources map[string]*api_resource.Quantity, resourceName string) bool {
+	if missingResourceQuantity(resources, resourceName) {
+		return false
+	}
+	quantity, err := resource.ParseQuantity(string(*resources[resourceName]))
+	return err == nil && quantity.IsZero()
+}
+
+// removeZeroQuantities removes the zero limit and request of the given
+// resource, which are then handled like the missing ones. Returns the types of
+// the removed quantities.
+func removeZeroQuantities(container *corev1.Container, resourceName string) []string {
+	removed := []string{}
+	resourceQuantities := []struct {
+		resourceType string
+		quantities   map[string]*api_resource.Quantity
+	}{
+		{"limit", container.Resources.Limits},
+		{"request", container.Resources.Requests},
+	}
+	for _, resourceQuantity := range resourceQuantities {
+		if isZeroResourceQuantity(resourceQuantity.quantities, resourceName) {
+			delete(resourceQuantity.quantities, resourceName)
+			removed = append(removed, resourceQuantity.resourceType)
+		}
+	}
+	return removed
+}
+
+// defaultRequestToLimit copies the limit of the given resource into the
+// missing request, like the API server does. Returns true when the container
+// has been mutated.
+func defaultRequestToLimit(container *corev1.Container, resourceName string) bool {
+	if !missingResourceQuantity(container.Resources.Requests, resourceName) || missingResourceQuantity(container.Resources.Limits, resourceName) {
+		return false
+	}
+	request := *container.Resources.Limits[resourceName]
+	container.Resources.Requests[resourceName] = &request
+	return true
+}
+
+// mirrorRequestAndLimit copies the limit of the given resource into the
+// missing request, or the request into the missing limit. Returns true when
+// the container has been mutated.
+func mirrorRequestAndLimit(container *corev1.Container, resourceName string) bool {
+	if missingResourceQuantity(container.Resources.Limits, resourceName) && !missingResourceQuantity(container.Resources.Requests, resourceName) {
+		limit := *container.Resources.Requests[resourceName]
+		container.Resources.Limits[resourceName] = &limit
+		return true
+	}
+	return defaultRequestToLimit(container, resourceName)
+}
+
+// validateContainerRequestEqualsLimit validates that the request of the given
+// resource is equal to its limit. The missing request defaults to the limit,
+// while a missing limit is unbounded. The containers not using the resource
+// are not validated.
+func validateContainerRequestEqualsLimit(container *corev1.Container, resourceName string) error {
+	if missingResourceQuantity(container.Resources.Requests, resourceName) {
+		return nil
+	}
+	if missingResourceQuantity(container.Resources.Limits, resourceName) {
+		return fmt.Errorf("container does not have %s limit. The %s request must be equal to the limit", withIndefiniteArticle(resourceName), resourceName)
+	}
+	limit, err := parseResourceQuantity(container.Resources.Limits, resourceName, "limit")
+	if err != nil {
+		return err
+	}
+	request, err := parseResourceQuantity(container.Resources.Requests, resourceName, "request")
+	if err != nil {
+		return err
+	}
+	if request.Cmp(limit) != 0 {
+		return fmt.Errorf("%s request '%s' is not equal to the limit '%s'. The %s request must be equal to the limit", resourceName, request.String(), limit.String(), resourceName)
+	}
+	return nil
+}
+
+// validateLimitRequestRatio validates that the limit is not greater than
+// the request multiplied by the MaxLimitRequestRatio, and that the difference
+// between the limit and the request is not greater than the
+// MaxLimitRequestDelta, when these are configured.
+func validateLimitRequestRatio(limit, request resource.Quantity, resourceConfig *ResourceConfiguration) error {
+	if resourceConfig.MaxLimitRequestRatio != nil {
+		if request.IsZero() {
+			return fmt.Errorf("the limit to request ratio is unbounded, because the request is zero. The max allowed ratio is '%s'", resourceConfig.MaxLimitRequestRatio.String())
+		}
+		// The ratio is rounded up at the precision of the quantities, which
+		// keeps the comparison exact
+		ratio, _ := limit.Div(request)
+		if ratio.Cmp(*resourceConfig.MaxLimitRequestRatio) > 0 {
+			return fmt.Errorf("limit '%s' is more than '%s' times the request '%s'", limit.String(), resourceConfig.MaxLimitRequestRatio.String(), request.String())
+		}
+	}
+	if resourceConfig.MaxLimitRequestDelta != nil {
+		delta := limit.DeepCopy()
+		delta.Sub(request)
+		if delta.Cmp(*resourceConfig.MaxLimitRequestDelta) > 0 {
+			return fmt.Errorf("limit '%s' exceeds the request '%s' by '%s', more than the max allowed delta '%s'", limit.String(), request.String(), delta.String(), resourceConfig.MaxLimitRequestDelta.String())
+		}
+	}
+	return nil
+}
+
+// validateContainerLimitRequestRatio validates the ratio and the delta
+// between the container limit and request of the given resource. The
+// containers not using the resource are not validated. The missing request
+// defaults to the limit, while a missing limit makes the ratio unbounded.
+func validateContainerLimitRequestRatio(container *corev1.Container, resourceName string, resourceConfig *ResourceConfiguration) error {
+	if resourceConfig.MaxLimitRequestRatio == nil && resourceConfig.MaxLimitRequestDelta == nil {
+		return nil
+	}
+	if missingResourceQuantity(container.Resources.Limits, resourceName) {
+		if missingResourceQuantity(container.Resources.Requests, resourceName) {
+			return nil
+		}
+		return fmt.Errorf("container does not have %s limit. The limit is required to bound the %s limit to request ratio", withIndefiniteArticle(resourceName), resourceName)
+	}
+	if missingResourceQuantity(container.Resources.Requests, resourceName) {
+		return nil
+	}
+	limit, err := parseResourceQuantity(container.Resources.Limits, resourceName, "limit")
+	if err != nil {
+		return err
+	}
+	request, err := parseResourceQuantity(container.Resources.Requests, resourceName, "request")
+	if err != nil {
+		return err
+	}
+	if err := validateLimitRequestRatio(limit, request, resourceConfig); err != nil {
+		return errors.Join(fmt.Errorf("invalid %s limit to request ratio", resourceName), err)
+	}
+	return nil
+}
+
 func parseResourceQuantity(resourceQuantities map[string]*api_resource.Quantity, resourceName string, resourceType string) (resource.Quantity, error) {
 	quantity := resourceQuantities[resourceName]
 	if quantity == nil {
@@ -156,57 +408,145 @@ func validateResourceMax(resourceQuantities map[string]*api_resource.Quantity, r
 	return nil
 }
 
+// enforceResourceMin validates that the resource limit/request value is
+// greater than or equal to the minimum allowed value. When onViolation is
+// clamp, the lower value is raised to the minimum instead of being rejected.
+// Returns true when the value has been clamped.
+func enforceResourceMin(resourceQuantities map[string]*api_resource.Quantity, resourceName string, minimum resource.Quantity, resourceType string, onViolation string) (bool, error) {
+	err := validateResourceMin(resourceQuantities, resourceName, minimum, resourceType)
+	return clampResourceQuantity(resourceQuantities, resourceName, minimum, resourceType, onViolation, err)
+}
+
+// enforceResourceMax validates that the resource limit/request value is less
+// than or equal to the maximum allowed value. When onViolation is clamp, the
+// greater value is lowered to the maximum instead of being rejected. Returns
+// true when the value has been clamped.
+func enforceResourceMax(resourceQuantities map[string]*api_resource.Quantity, resourceName string, maximum resource.Quantity, resourceType string, onViolation string) (bool, error) {
+	err := validateResourceMax(resourceQuantities, resourceName, maximum, resourceType)
+	return clampResourceQuantity(resourceQuantities, resourceName, maximum, resourceType, onViolation, err)
+}
+
+// clampResourceQuantity sets the resource limit/request value to the given
+// bound when the value violates it and onViolation is clamp. The values which
+// cannot be parsed are always rejected.
+func clampResourceQuantity(resourceQuantities map[string]*api_resource.Quantity, resourceName string, bound resource.Quantity, resourceType string, onViolation string, violation error) (bool, error) {
+	if violation == nil || onViolation != onViolationClamp {
+		return false, violation
+	}
+	if _, err := parseResourceQuantity(resourceQuantities, resourceName, resourceType); err != nil {
+		return false, err
+	}
+	clamped := api_resource.Quantity(bound.String())
+	resourceQuantities[resourceName] = &clamped
+	return true, nil
+}
+
+// validateNonOvercommittableResource validates a resource which cannot be
+// overcommitted, like hugepages and extended resources. Kubernetes requires
+// the request of these resources to be equal to the limit: a missing request
+// defaults to the limit, while a request without a limit is not allowed.
+//
+// When resourceConfig is not nil, the limit, and the request defaulting to
+// it, must also fall in the configured ranges.
+func validateNonOvercommittableResource(container *corev1.Container, resourceName string, resourceConfig *ResourceConfiguration) error {
+	if missingResourceQuantity(container.Resources.Limits, resourceName) {
+		return fmt.Errorf("container does not have %s limit. The %s request must be equal to the limit", withIndefiniteArticle(resourceName), resourceName)
+	}
+	if err := validateContainerRequestEqualsLimit(container, resourceName); err != nil {
+		return err
+	}
+	if resourceConfig == nil {
+		return nil
+	}
+
+	if _, err := validateContainerResourceLimitsAndRequests(container, resourceName, resourceConfig); err != nil {
+		return err
+	}
+	// The missing request defaults to the limit
+	if missingResourceQuantity(container.Resources.Requests, resourceName) {
+		if resourceConfig.MinRequest != nil {
+			if err := validateResourceMin(container.Resources.Limits, resourceName, *resourceConfig.MinRequest, "request"); err != nil {
+				return err
+			}
+		}
+		if resourceConfig.MaxRequest != nil {
+			if err := validateResourceMax(container.Resources.Limits, resourceName, *resourceConfig.MaxRequest, "request"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // validateContainerResourceLimitsAndRequests validates the container against the
 // passed resourceConfig and mutates it if the validation didn't pass.
 //
 // When the CPU/Memory limit is not specified: the container is mutated to use
-// the `defaultLimit`.
+// the `defaultLimit`, or the limit derived from the request, which is then
+// validated like the container limits.
 //
 // When the CPU/Memory limit is specified: the resource request falls in the following ranges:
 // minRequest <= {request} <= maxRequest <= minLimit <= {limit} <= maxLimit
-// or IgnoreValues is true. Otherwise the request is rejected.
+// or IgnoreValues is true. Otherwise the request is rejected, unless
+// OnViolation is clamp: then the values out of range are set to the nearest
+// bound.
 //
 // Returns true when it mutates the container.
 func validateContainerResourceLimitsAndRequests(container *corev1.Container, resourceName string, resourceConfig *ResourceConfiguration) (bool, error) {
 	mutated := false
+	validateLimit := true
 	if missingResourceQuantity(container.Resources.Limits, resourceName) {
-		if !resourceConfig.DefaultLimit.IsZero() {
+		defaultLimit, derived, err := resourceConfig.defaultLimit(container, resourceName)
+		if err != nil {
+			return false, err
+		}
+		if defaultLimit != nil {
 			// If the container doesn't have a limit, and the settings have a default limit,
 			// mutate and add the default limit
-			newLimit := api_resource.Quantity(resourceConfig.DefaultLimit.String())
+			newLimit := api_resource.Quantity(defaultLimit.String())
 			container.Resources.Limits[resourceName] = &newLimit
 			// return after all checks are done
 			mutated = true
 		}
-	} else { // the container has a limit
-		if !resourceConfig.MaxLimit.IsZero() {
+		validateLimit = derived
+	}
+	if validateLimit { // the container has a limit, or a derived one
+		if resourceConfig.MaxLimit != nil {
 			// The settings have a maxLimit, check that the container limit is <= maxLimit
-			if err := validateResourceMax(container.Resources.Limits, resourceName, resourceConfig.MaxLimit, "limit"); err != nil {
+			clamped, err := enforceResourceMax(container.Resources.Limits, resourceName, *resourceConfig.MaxLimit, "limit", resourceConfig.OnViolation)
+			if err != nil {
 				return false, err
 			}
+			mutated = clamped || mutated
 		}
 
-		if !resourceConfig.MinLimit.IsZero() {
+		if resourceConfig.MinLimit != nil {
 			// The settings have a minLimit, check that the container limit is >= minLimit
-			if err := validateResourceMin(container.Resources.Limits, resourceName, resourceConfig.MinLimit, "limit"); err != nil {
+			clamped, err := enforceResourceMin(container.Resources.Limits, resourceName, *resourceConfig.MinLimit, "limit", resourceConfig.OnViolation)
+			if err != nil {
 				return false, err
 			}
+			mutated = clamped || mutated
 		}
 	}
 
 	if !missingResourceQuantity(container.Resources.Requests, resourceName) {
-		if !resourceConfig.MinRequest.IsZero() {
+		if resourceConfig.MinRequest != nil {
 			// The container has a request,
 			// and the settings have a minRequest, check that the container request is >= minRequest
-			if err := validateResourceMin(container.Resources.Requests, resourceName, resourceConfig.MinRequest, "request"); err != nil {
+			clamped, err := enforceResourceMin(container.Resources.Requests, resourceName, *resourceConfig.MinRequest, "request", resourceConfig.OnViolation)
+			if err != nil {
 				return false, err
 			}
+			mutated = clamped || mutated
 		}
-		if !resourceConfig.MaxRequest.IsZero() {
+		if resourceConfig.MaxRequest != nil {
 			// The settings have a maxRequest, check that the container request is <= maxRequest
-			if err := validateResourceMax(container.Resources.Requests, resourceName, resourceConfig.MaxRequest, "request"); err != nil {
+			clamped, err := enforceResourceMax(container.Resources.Requests, resourceName, *resourceConfig.MaxRequest, "request", resourceConfig.OnViolation)
+			if err != nil {
 				return false, err
 			}
+			mutated = clamped || mutated
 		}
 	}
 
@@ -226,20 +566,15 @@ func validateContainerResourceLimitsAndRequests(container *corev1.Container, res
 // Return `true` when the container has been mutated.
 func validateAndAdjustContainerConstraints(container *corev1.Container, settings *Settings) (bool, error) {
 	mutated := false
-	if !settings.shouldIgnoreMemoryValues() && settings.Memory != nil {
-		var err error
-		mutated, err = validateContainerResourceLimitsAndRequests(container, "memory", settings.Memory)
-		if err != nil {
-			return false, err
+	for _, resourceSettings := range settings.resourceSettings() {
+		if settings.shouldIgnoreValues(resourceSettings.resourceName) {
+			continue
 		}
-	}
-
-	if !settings.shouldIgnoreCpuValues() && settings.Cpu != nil {
-		cpuMutation, err := validateContainerResourceLimitsAndRequests(container, "cpu", settings.Cpu)
+		resourceMutated, err := validateContainerResourceLimitsAndRequests(container, resourceSettings.resourceName, resourceSettings.configuration)
 		if err != nil {
 			return false, err
 		}
-		mutated = mutated || cpuMutation
+		mutated = mutated || resourceMutated
 	}
 	return mutated, nil
 }
@@ -261,18 +596,66 @@ func validateAndAdjustContainer(container *corev1.Container, settings *Settings)
 		container.Resources.Requests = make(map[string]*api_resource.Quantity)
 	}
 
+	// The rejected zero values and the forbidden limits are removed, the
+	// values are rounded up to the step, and the values copied between the
+	// request and the limit take precedence over the default values. All of
+	// them happen before validating the container values. The API server
+	// defaulting, when emulated, happens before removing the forbidden limits,
+	// like in the real API server.
+	preValidationMutation := false
+	zeroQuantities := make(map[string][]string)
+	for _, resourceSettings := range settings.resourceSettings() {
+		if resourceSettings.configuration.RejectZero {
+			zeroQuantities[resourceSettings.resourceName] = removeZeroQuantities(container, resourceSettings.resourceName)
+			preValidationMutation = len(zeroQuantities[resourceSettings.resourceName]) > 0 || preValidationMutation
+		}
+		if settings.DefaultingMode == defaultingModeKubernetes {
+			preValidationMutation = defaultRequestToLimit(container, resourceSettings.resourceName) || preValidationMutation
+		}
+		if resourceSettings.configuration.limitForbidden() {
+			forbiddenLimitMutation, err := adjustForbiddenLimit(container, resourceSettings.resourceName, resourceSettings.configuration)
+			if err != nil {
+				return false, err
+			}
+			preValidationMutation = forbiddenLimitMutation || preValidationMutation
+		}
+		if !settings.shouldIgnoreValues(resourceSettings.resourceName) {
+			stepMutation, err := adjustResourceStep(container, resourceSettings.resourceName, resourceSettings.configuration)
+			if err != nil {
+				return false, err
+			}
+			preValidationMutation = stepMutation || preValidationMutation
+		}
+		if resourceSettings.configuration.MirrorRequestAndLimit {
+			preValidationMutation = mirrorRequestAndLimit(container, resourceSettings.resourceName) || preValidationMutation
+		}
+	}
+
+	// The repair strategies change only the injected default values, and the
+	// fixed default requests take precedence over the ones derived from an
+	// injected limit
+	missingLimits := make(map[string]bool)
+	missingRequests := make(map[string]bool)
+	for _, resourceSettings := range settings.resourceSettings() {
+		missingLimits[resourceSettings.resourceName] = missingResourceQuantity(container.Resources.Limits, resourceSettings.resourceName)
+		missingRequests[resourceSettings.resourceName] = missingResourceQuantity(container.Resources.Requests, resourceSettings.resourceName)
+	}
+
 	// Check if container resource configuration is compliant with  minLimit, maxLimit, minRequest, and maxRequest settings.
 	limitsMutation, err := validateAndAdjustContainerConstraints(container, settings)
 	if err != nil {
 		return false, err
 	}
 	// mutate the requests
-	requestsMutation := validateAndAdjustContainerResourceRequests(container, settings)
+	requestsMutation, err := validateAndAdjustContainerResourceRequests(container, settings, missingLimits)
+	if err != nil {
+		return false, err
+	}
 
 	if limitsMutation || requestsMutation {
 		// If the container has been mutated with the default values, we need to
-		// check that the limit is greater than the request for both CPU and
-		// Memory.
+		// check that the limit is greater than the request for all the
+		// configured resources.
 		// If the limit is less than the request, we reject the request. Because
 		// the user need to adjust the resource or change the policy configuration.
 		// Otherwise, Kubernetes will not accept the resource mutated by the
@@ -281,14 +664,47 @@ func validateAndAdjustContainer(container *corev1.Container, settings *Settings)
 		if requestsMutation {
 			errorMsg = "There is an issue after resource requests mutation"
 		}
-		if err := isResourceLimitGreaterThanRequest(container, "memory"); err != nil {
-			return false, errors.Join(errors.New(errorMsg), err)
+		for _, resourceSettings := range settings.resourceSettings() {
+			resourceName := resourceSettings.resourceName
+			repaired, err := repairInjectedDefault(container, resourceName, resourceSettings.configuration,
+				missingLimits[resourceName] && !missingResourceQuantity(container.Resources.Limits, resourceName),
+				missingRequests[resourceName] && !missingResourceQuantity(container.Resources.Requests, resourceName))
+			if err != nil {
+				return false, errors.Join(errors.New(errorMsg), err)
+			}
+			limitsMutation = repaired || limitsMutation
+			if err := isResourceLimitGreaterThanRequest(container, resourceName); err != nil {
+				return false, errors.Join(errors.New(errorMsg), err)
+			}
 		}
-		if err := isResourceLimitGreaterThanRequest(container, "cpu"); err != nil {
-			return false, errors.Join(errors.New(errorMsg), err)
+	}
+
+	// The rejected zero values, the required limits, the overcommit and the
+	// request equal to the limit are checked after applying the default values
+	for _, resourceSettings := range settings.resourceSettings() {
+		// The zero values not replaced by a default value are rejected
+		for _, resourceType := range zeroQuantities[resourceSettings.resourceName] {
+			quantities := container.Resources.Requests
+			if resourceType == "limit" {
+				quantities = container.Resources.Limits
+			}
+			if missingResourceQuantity(quantities, resourceSettings.resourceName) {
+				return false, fmt.Errorf("container has a zero %s %s, which is not allowed", resourceSettings.resourceName, resourceType)
+			}
+		}
+		if err := validateContainerLimitRequestRatio(container, resourceSettings.resourceName, resourceSettings.configuration); err != nil {
+			return false, err
+		}
+		if resourceSettings.configuration.LimitPolicy == limitPolicyRequired && missingResourceQuantity(container.Resources.Limits, resourceSettings.resourceName) {
+			return false, fmt.Errorf("container does not have %s limit, which is required", withIndefiniteArticle(resourceSettings.resourceName))
+		}
+		if resourceSettings.configuration.RequireRequestEqualsLimit {
+			if err := validateContainerRequestEqualsLimit(container, resourceSettings.resourceName); err != nil {
+				return false, err
+			}
 		}
 	}
-	return limitsMutation || requestsMutation, nil
+	return preValidationMutation || limitsMutation || requestsMutation, nil
 }
 
 func shouldSkipContainer(image string, ignoreImages []string) bool {
@@ -307,25 +723,261 @@ func shouldSkipContainer(image string, ignoreImages []string) bool {
 	return false
 }
 
-func validatePodSpec(pod *corev1.PodSpec, settings *Settings) (bool, error) {
+// validateContainers validates and adjusts all the given containers using the
+// passed settings. The pod-level resources, when defined, can be used in place
+// of the container limits and requests. Returns true when at least one
+// container has been mutated.
+func validateContainers(containers []*corev1.Container, podResources *corev1.ResourceRequirements, settings *Settings) (bool, error) {
 	mutated := false
-	for _, container := range pod.Containers {
+	containerSettings := settings.withoutPodResourcesDefaults(podResources)
+	for _, container := range containers {
 		if shouldSkipContainer(container.Image, settings.IgnoreImages) {
 			continue
 		}
-		if err := validateContainerCheckPresence(container, settings); err != nil {
+		// The units are checked before injecting the default values
+		if err := validateUnitHygiene(container.Resources, fmt.Sprintf("container '%s'", containerName(container)), settings.UnitHygiene); err != nil {
+			return false, err
+		}
+		if err := validateContainerCheckPresence(withPodResources(container, podResources), settings); err != nil {
 			return false, err
 		}
 
-		containerMutated, err := validateAndAdjustContainer(container, settings)
+		containerMutated, err := validateAndAdjustContainer(container, containerSettings)
 		if err != nil {
 			return false, err
 		}
-		mutated = mutated || containerMutated
+		// The hugepages and the extended resources rules depend on the cpu and
+		// memory values, which could have been injected by the policy.
+		if err := validateContainerHugepages(container, settings); err != nil {
+			return false, err
+		}
+		if err := validateContainerExtendedResources(container, settings); err != nil {
+			return false, err
+		}
+		canonicalizeMutated, err := canonicalizeResources(container.Resources, settings.Canonicalize)
+		if err != nil {
+			return false, err
+		}
+		mutated = mutated || containerMutated || canonicalizeMutated
 	}
 	return mutated, nil
 }
 
+// emptyDirSizeLimits returns the size limit of the emptyDir volumes of the
+// Pod which are not backed by memory. The emptyDir volumes without a size
+// limit are not included.
+func emptyDirSizeLimits(pod *corev1.PodSpec) (map[string]resource.Quantity, error) {
+	sizeLimits := make(map[string]resource.Quantity)
+	for _, volume := range pod.Volumes {
+		if volume.Name == nil || volume.EmptyDir == nil || volume.EmptyDir.Medium == emptyDirMediumMemory || volume.EmptyDir.SizeLimit == nil {
+			continue
+		}
+		sizeLimit, err := resource.ParseQuantity(string(*volume.EmptyDir.SizeLimit))
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("invalid emptyDir volume '%s' size limit", *volume.Name), err)
+		}
+		sizeLimits[*volume.Name] = sizeLimit
+	}
+	return sizeLimits, nil
+}
+
+// validateEmptyDirSizeLimits checks that the sum of the size limits of the
+// emptyDir volumes mounted by a container is less than or equal to the
+// ephemeral-storage limit of the container. The emptyDir volumes backed by
+// memory are accounted as memory, therefore they are not included. The
+// containers without an ephemeral-storage limit are not checked.
+func validateEmptyDirSizeLimits(pod *corev1.PodSpec, settings *Settings) error {
+	sizeLimits, err := emptyDirSizeLimits(pod)
+	if err != nil || len(sizeLimits) == 0 {
+		return err
+	}
+	containers := append(append([]*corev1.Container{}, pod.Containers...), pod.InitContainers...)
+	for _, container := range containers {
+		if shouldSkipContainer(container.Image, settings.IgnoreImages) ||
+			container.Resources == nil ||
+			missingResourceQuantity(container.Resources.Limits, "ephemeral-storage") {
+			continue
+		}
+		ephemeralStorageLimit, err := parseResourceQuantity(container.Resources.Limits, "ephemeral-storage", "limit")
+		if err != nil {
+			return err
+		}
+		total := resource.Quantity{}
+		mountedVolumes := make(map[string]bool)
+		for _, volumeMount := range container.VolumeMounts {
+			if volumeMount.Name == nil || mountedVolumes[*volumeMount.Name] {
+				continue
+			}
+			mountedVolumes[*volumeMount.Name] = true
+			if sizeLimit, found := sizeLimits[*volumeMount.Name]; found {
+				total.Add(sizeLimit)
+			}
+		}
+		if total.Cmp(ephemeralStorageLimit) > 0 {
+			return fmt.Errorf("container '%s' mounts emptyDir volumes with a total size limit of '%s', which exceeds its ephemeral-storage limit '%s'", containerName(container), total.String(), ephemeralStorageLimit.String())
+		}
+	}
+	return nil
+}
+
+// isSidecarContainer returns true when the given init container is a native
+// sidecar container. Sidecar containers keep running for the whole life of
+// the Pod, like the app containers.
+func isSidecarContainer(container *corev1.Container) bool {
+	return container.RestartPolicy == containerRestartPolicyAlways
+}
+
+// splitInitContainers splits the init containers into the regular init
+// containers and the native sidecar containers.
+func splitInitContainers(initContainers []*corev1.Container) ([]*corev1.Container, []*corev1.Container) {
+	regular := []*corev1.Container{}
+	sidecars := []*corev1.Container{}
+	for _, container := range initContainers {
+		if isSidecarContainer(container) {
+			sidecars = append(sidecars, container)
+		} else {
+			regular = append(regular, container)
+		}
+	}
+	return regular, sidecars
+}
+
+// validatePodSpec validates and adjusts the pod-level resources and the
+// containers of the Pod. podResources holds the pod-level resources, which
+// could be mutated as well. Returns true when the Pod has been mutated.
+func validatePodSpec(pod *corev1.PodSpec, podResources *corev1.ResourceRequirements, settings *Settings) (bool, error) {
+	if podResources == nil {
+		podResources = &corev1.ResourceRequirements{}
+	}
+	if settings.IntegerCpu != nil && settings.IntegerCpu.RoundUpDefaults {
+		settings = settings.withRoundedUpCpuDefaults()
+	}
+	if err := validateUnitHygiene(podResources, "the pod-level resources", settings.UnitHygiene); err != nil {
+		return false, err
+	}
+	podResourcesMutated := false
+	if podSettings := settings.podSettings(); podSettings != nil {
+		var err error
+		podResourcesMutated, err = validateAndAdjustPodResources(podResources, podSettings)
+		if err != nil {
+			return false, errors.Join(errors.New("invalid pod-level resources"), err)
+		}
+	}
+	podResourcesCanonicalized, err := canonicalizeResources(podResources, settings.Canonicalize)
+	if err != nil {
+		return false, err
+	}
+	podResourcesMutated = podResourcesMutated || podResourcesCanonicalized
+
+	mutated, err := validateContainers(pod.Containers, podResources, settings)
+	if err != nil {
+		return false, err
+	}
+
+	initContainers, sidecars := splitInitContainers(pod.InitContainers)
+	sidecarsMutated, err := validateContainers(sidecars, podResources, settings.sidecarSettings())
+	if err != nil {
+		return false, errors.Join(errors.New("invalid sidecar container"), err)
+	}
+
+	initContainersMutated, err := validateContainers(initContainers, podResources, settings.initContainerSettings())
+	if err != nil {
+		return false, errors.Join(errors.New("invalid init container"), err)
+	}
+
+	if err := validatePodResourcesBudget(pod, podResources); err != nil {
+		return false, err
+	}
+	if err := validatePodEffectiveResources(pod, podResources, settings); err != nil {
+		return false, err
+	}
+	if err := validatePodQOSClass(pod, podResources, settings); err != nil {
+		return false, err
+	}
+	if err := validateIntegerCpu(pod, podResources, settings); err != nil {
+		return false, err
+	}
+	if settings.CheckEmptyDirSizeLimit {
+		if err := validateEmptyDirSizeLimits(pod, settings); err != nil {
+			return false, err
+		}
+	}
+	return podResourcesMutated || mutated || sidecarsMutated || initContainersMutated, nil
+}
+
+// validateEphemeralContainers validates and adjusts the ephemeral containers
+// of the Pod which are not defined in the old Pod. The ephemeral containers
+// cannot be changed once added to the Pod, therefore only the ones added by
+// the request are validated.
+//
+// Returns true when at least one ephemeral container has been mutated.
+func validateEphemeralContainers(pod *corev1.PodSpec, oldPod *corev1.PodSpec, settings *Settings) (bool, error) {
+	existingContainers := make(map[string]bool)
+	if oldPod != nil {
+		for _, ephemeralContainer := range oldPod.EphemeralContainers {
+			if ephemeralContainer.Name != nil {
+				existingContainers[*ephemeralContainer.Name] = true
+			}
+		}
+	}
+
+	mutated := false
+	for _, ephemeralContainer := range pod.EphemeralContainers {
+		if ephemeralContainer.Name != nil && existingContainers[*ephemeralContainer.Name] {
+			continue
+		}
+		container := &corev1.Container{
+			Name:      ephemeralContainer.Name,
+			Image:     ephemeralContainer.Image,
+			Resources: ephemeralContainer.Resources,
+		}
+		containerMutated, err := validateContainers([]*corev1.Container{container}, nil, settings)
+		if err != nil {
+			return false, errors.Join(errors.New("invalid ephemeral container"), err)
+		}
+		if containerMutated {
+			ephemeralContainer.Resources = container.Resources
+			mutated = true
+		}
+	}
+	return mutated, nil
+}
+
+// validateEphemeralContainersRequest validates a request targeting the
+// pods/ephemeralcontainers subresource. The request object is the whole Pod,
+// but only its ephemeral containers can be changed.
+func validateEphemeralContainersRequest(validationRequest *kubewarden_protocol.ValidationRequest, settings *Settings) ([]byte, error) {
+	ephemeralContainerSettings := settings.ephemeralContainerSettings()
+	if ephemeralContainerSettings == nil {
+		return kubewarden.AcceptRequest()
+	}
+
+	pod := corev1.Pod{}
+	if err := json.Unmarshal(validationRequest.Request.Object, &pod); err != nil {
+		return kubewarden.RejectRequest(kubewarden.Message(err.Error()), kubewarden.Code(400))
+	}
+	if pod.Spec == nil {
+		return kubewarden.AcceptRequest()
+	}
+	oldPod := corev1.Pod{}
+	if len(validationRequest.Request.OldObject) > 0 {
+		if err := json.Unmarshal(validationRequest.Request.OldObject, &oldPod); err != nil {
+			return kubewarden.RejectRequest(kubewarden.Message(err.Error()), kubewarden.Code(400))
+		}
+	}
+
+	mutatePod, errValidate := validateEphemeralContainers(pod.Spec, oldPod.Spec, ephemeralContainerSettings)
+	if errValidate != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(errValidate.Error()),
+			kubewarden.Code(400))
+	}
+	if mutatePod {
+		return kubewarden.MutateRequest(pod)
+	}
+	return kubewarden.AcceptRequest()
+}
+
 func validate(payload []byte) ([]byte, error) {
 	// Create a ValidationRequest instance from the incoming payload
 	validationRequest := kubewarden_protocol.ValidationRequest{}
@@ -344,19 +996,43 @@ func validate(payload []byte) ([]byte, error) {
 			kubewarden.Code(400))
 	}
 
+	if validationRequest.Request.SubResource == ephemeralContainersSubResource {
+		return validateEphemeralContainersRequest(&validationRequest, &settings)
+	}
+
 	podSpec, err := kubewarden.ExtractPodSpecFromObject(validationRequest)
 	if err != nil {
 		return kubewarden.RejectRequest(kubewarden.Message(err.Error()), kubewarden.Code(400))
 	}
 
-	mutatePod, errValidate := validatePodSpec(&podSpec, &settings)
+	podResources, err := extractPodResources(&validationRequest)
+	if err != nil {
+		return kubewarden.RejectRequest(kubewarden.Message(err.Error()), kubewarden.Code(400))
+	}
+	if podResources == nil {
+		podResources = &corev1.ResourceRequirements{}
+	}
+
+	// The Pod labels are not part of the PodSpec, therefore the Pods requiring
+	// integer CPU quantities are selected here.
+	if settings.IntegerCpu != nil {
+		podLabels, err := extractPodLabels(&validationRequest)
+		if err != nil {
+			return kubewarden.RejectRequest(kubewarden.Message(err.Error()), kubewarden.Code(400))
+		}
+		if !settings.IntegerCpu.selects(&podSpec, podLabels) {
+			settings.IntegerCpu = nil
+		}
+	}
+
+	mutatePod, errValidate := validatePodSpec(&podSpec, podResources, &settings)
 	if errValidate != nil {
 		return kubewarden.RejectRequest(
 			kubewarden.Message(errValidate.Error()),
 			kubewarden.Code(400))
 	}
 	if mutatePod {
-		return kubewarden.MutatePodSpecFromRequest(validationRequest, podSpec)
+		return mutatePodSpecFromRequest(validationRequest, podSpec, podResources)
 	}
 
 	return kubewarden.AcceptRequest()
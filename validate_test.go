@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -9,6 +10,8 @@ import (
 	"github.com/kubewarden/container-resources-policy/resource"
 	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
 	apimachinery_pkg_api_resource "github.com/kubewarden/k8s-objects/apimachinery/pkg/api/resource"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+	kubewarden_testing "github.com/kubewarden/policy-sdk-go/testing"
 )
 
 func TestContainerIsRequiredToHaveLimits(t *testing.T) {
@@ -36,12 +39,12 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			corev1.Container{},
 			Settings{
 				Cpu: &ResourceConfiguration{
-					DefaultRequest: oneCore,
-					DefaultLimit:   oneCore,
+					DefaultRequest: &oneCore,
+					DefaultLimit:   &oneCore,
 				},
 				Memory: &ResourceConfiguration{
-					DefaultRequest: oneGi,
-					DefaultLimit:   oneGi,
+					DefaultRequest: &oneGi,
+					DefaultLimit:   &oneGi,
 				},
 				IgnoreImages: []string{},
 			},
@@ -71,14 +74,14 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 			Settings{
 				Cpu: &ResourceConfiguration{
-					DefaultLimit:   oneCore,
-					DefaultRequest: oneCore,
-					MaxLimit:       oneCore,
+					DefaultLimit:   &oneCore,
+					DefaultRequest: &oneCore,
+					MaxLimit:       &oneCore,
 				},
 				Memory: &ResourceConfiguration{
-					DefaultLimit:   oneGi,
-					DefaultRequest: oneGi,
-					MaxLimit:       oneGi,
+					DefaultLimit:   &oneGi,
+					DefaultRequest: &oneGi,
+					MaxLimit:       &oneGi,
 				},
 				IgnoreImages: []string{},
 			},
@@ -109,14 +112,14 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 
 			Settings{
 				Cpu: &ResourceConfiguration{
-					DefaultLimit:   oneCore,
-					DefaultRequest: oneCore,
-					MaxLimit:       oneCore,
+					DefaultLimit:   &oneCore,
+					DefaultRequest: &oneCore,
+					MaxLimit:       &oneCore,
 				},
 				Memory: &ResourceConfiguration{
-					DefaultLimit:   oneGi,
-					DefaultRequest: oneGi,
-					MaxLimit:       oneGi,
+					DefaultLimit:   &oneGi,
+					DefaultRequest: &oneGi,
+					MaxLimit:       &oneGi,
 				},
 				IgnoreImages: []string{},
 			},
@@ -147,16 +150,16 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 			Settings{
 				Cpu: &ResourceConfiguration{
-					MinRequest:     oneCore,
-					MaxLimit:       twoCore,
-					DefaultLimit:   twoCore,
-					DefaultRequest: twoCore,
+					MinRequest:     &oneCore,
+					MaxLimit:       &twoCore,
+					DefaultLimit:   &twoCore,
+					DefaultRequest: &twoCore,
 				},
 				Memory: &ResourceConfiguration{
-					MinRequest:     oneGi,
-					MaxLimit:       twoGi,
-					DefaultLimit:   twoGi,
-					DefaultRequest: twoGi,
+					MinRequest:     &oneGi,
+					MaxLimit:       &twoGi,
+					DefaultLimit:   &twoGi,
+					DefaultRequest: &twoGi,
 				},
 			},
 			&corev1.ResourceRequirements{
@@ -183,14 +186,14 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 		}, Settings{
 			Cpu: &ResourceConfiguration{
-				DefaultLimit:   oneCore,
-				DefaultRequest: oneCore,
-				MaxLimit:       oneCore,
+				DefaultLimit:   &oneCore,
+				DefaultRequest: &oneCore,
+				MaxLimit:       &oneCore,
 			},
 			Memory: &ResourceConfiguration{
-				DefaultLimit:   oneGi,
-				DefaultRequest: oneGi,
-				MaxLimit:       oneGi,
+				DefaultLimit:   &oneGi,
+				DefaultRequest: &oneGi,
+				MaxLimit:       &oneGi,
 			},
 		}, &corev1.ResourceRequirements{
 			Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
@@ -212,14 +215,14 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 		}, Settings{
 			Cpu: &ResourceConfiguration{
-				DefaultLimit:   oneCore,
-				DefaultRequest: oneCore,
-				MaxLimit:       oneCore,
+				DefaultLimit:   &oneCore,
+				DefaultRequest: &oneCore,
+				MaxLimit:       &oneCore,
 			},
 			Memory: &ResourceConfiguration{
-				DefaultLimit:   oneGi,
-				DefaultRequest: oneGi,
-				MaxLimit:       oneGi,
+				DefaultLimit:   &oneGi,
+				DefaultRequest: &oneGi,
+				MaxLimit:       &oneGi,
 			},
 		}, &corev1.ResourceRequirements{
 			Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
@@ -241,15 +244,15 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 		}, Settings{
 			Cpu: &ResourceConfiguration{
-				DefaultLimit:   twoCore,
-				DefaultRequest: twoCore,
-				MinRequest:     twoCore,
-				MaxLimit:       twoCore,
+				DefaultLimit:   &twoCore,
+				DefaultRequest: &twoCore,
+				MinRequest:     &twoCore,
+				MaxLimit:       &twoCore,
 			},
 			Memory: &ResourceConfiguration{
-				DefaultLimit:   twoGi,
-				DefaultRequest: twoGi,
-				MaxLimit:       twoGi,
+				DefaultLimit:   &twoGi,
+				DefaultRequest: &twoGi,
+				MaxLimit:       &twoGi,
 			},
 		}, &corev1.ResourceRequirements{
 			Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
@@ -277,14 +280,14 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 			Settings{
 				Cpu: &ResourceConfiguration{
-					DefaultLimit:   oneCore,
-					DefaultRequest: oneCore,
-					MaxLimit:       oneCore,
+					DefaultLimit:   &oneCore,
+					DefaultRequest: &oneCore,
+					MaxLimit:       &oneCore,
 				},
 				Memory: &ResourceConfiguration{
-					DefaultLimit:   oneGi,
-					DefaultRequest: oneGi,
-					MaxLimit:       oneGi,
+					DefaultLimit:   &oneGi,
+					DefaultRequest: &oneGi,
+					MaxLimit:       &oneGi,
 				},
 				IgnoreImages: []string{},
 			},
@@ -315,14 +318,14 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 
 			Settings{
 				Cpu: &ResourceConfiguration{
-					DefaultLimit:   oneCore,
-					DefaultRequest: oneCore,
-					MaxLimit:       oneCore,
+					DefaultLimit:   &oneCore,
+					DefaultRequest: &oneCore,
+					MaxLimit:       &oneCore,
 				},
 				Memory: &ResourceConfiguration{
-					DefaultLimit:   oneGi,
-					DefaultRequest: oneGi,
-					MaxLimit:       oneGi,
+					DefaultLimit:   &oneGi,
+					DefaultRequest: &oneGi,
+					MaxLimit:       &oneGi,
 				},
 				IgnoreImages: []string{},
 			},
@@ -354,14 +357,14 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 			Settings{
 				Cpu: &ResourceConfiguration{
-					DefaultLimit:   oneCore,
-					DefaultRequest: oneCore,
-					MaxLimit:       oneCore,
+					DefaultLimit:   &oneCore,
+					DefaultRequest: &oneCore,
+					MaxLimit:       &oneCore,
 				},
 				Memory: &ResourceConfiguration{
-					DefaultLimit:   oneGi,
-					DefaultRequest: oneGi,
-					MaxLimit:       oneGi,
+					DefaultLimit:   &oneGi,
+					DefaultRequest: &oneGi,
+					MaxLimit:       &oneGi,
 				},
 				IgnoreImages: []string{},
 			},
@@ -392,14 +395,14 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 			Settings{
 				Cpu: &ResourceConfiguration{
-					DefaultLimit:   oneCore,
-					DefaultRequest: oneCore,
-					MaxLimit:       oneCore,
+					DefaultLimit:   &oneCore,
+					DefaultRequest: &oneCore,
+					MaxLimit:       &oneCore,
 				},
 				Memory: &ResourceConfiguration{
-					DefaultLimit:   oneGi,
-					DefaultRequest: oneGi,
-					MaxLimit:       oneGi,
+					DefaultLimit:   &oneGi,
+					DefaultRequest: &oneGi,
+					MaxLimit:       &oneGi,
 				},
 				IgnoreImages: []string{},
 			},
@@ -428,15 +431,15 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 		}, Settings{
 			Cpu: &ResourceConfiguration{
-				DefaultLimit:   oneCore,
-				DefaultRequest: oneCore,
-				MaxLimit:       oneCore,
+				DefaultLimit:   &oneCore,
+				DefaultRequest: &oneCore,
+				MaxLimit:       &oneCore,
 			},
 			Memory: &ResourceConfiguration{
 				IgnoreValues:   true,
-				DefaultLimit:   oneGi,
-				DefaultRequest: oneGi,
-				MaxLimit:       oneGi,
+				DefaultLimit:   &oneGi,
+				DefaultRequest: &oneGi,
+				MaxLimit:       &oneGi,
 			},
 		}, &corev1.ResourceRequirements{
 			Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
@@ -462,14 +465,14 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 		}, Settings{
 			Cpu: &ResourceConfiguration{
 				IgnoreValues:   true,
-				DefaultLimit:   oneCore,
-				DefaultRequest: oneCore,
-				MaxLimit:       oneCore,
+				DefaultLimit:   &oneCore,
+				DefaultRequest: &oneCore,
+				MaxLimit:       &oneCore,
 			},
 			Memory: &ResourceConfiguration{
-				DefaultLimit:   oneGi,
-				DefaultRequest: oneGi,
-				MaxLimit:       oneGi,
+				DefaultLimit:   &oneGi,
+				DefaultRequest: &oneGi,
+				MaxLimit:       &oneGi,
 			},
 		}, &corev1.ResourceRequirements{
 			Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
@@ -497,14 +500,14 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 
 			Settings{
 				Cpu: &ResourceConfiguration{
-					DefaultLimit:   oneCore,
-					DefaultRequest: oneCore,
-					MaxLimit:       oneCore,
+					DefaultLimit:   &oneCore,
+					DefaultRequest: &oneCore,
+					MaxLimit:       &oneCore,
 				},
 				Memory: &ResourceConfiguration{
-					DefaultLimit:   oneGi,
-					DefaultRequest: oneGi,
-					MaxLimit:       oneGi,
+					DefaultLimit:   &oneGi,
+					DefaultRequest: &oneGi,
+					MaxLimit:       &oneGi,
 				},
 				IgnoreImages: []string{},
 			},
@@ -535,14 +538,14 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 
 			Settings{
 				Cpu: &ResourceConfiguration{
-					DefaultLimit:   oneCore,
-					DefaultRequest: oneCore,
-					MaxLimit:       oneCore,
+					DefaultLimit:   &oneCore,
+					DefaultRequest: &oneCore,
+					MaxLimit:       &oneCore,
 				},
 				Memory: &ResourceConfiguration{
-					DefaultLimit:   oneGi,
-					DefaultRequest: oneGi,
-					MaxLimit:       oneGi,
+					DefaultLimit:   &oneGi,
+					DefaultRequest: &oneGi,
+					MaxLimit:       &oneGi,
 				},
 				IgnoreImages: []string{},
 			},
@@ -571,10 +574,10 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 			Settings{
 				Cpu: &ResourceConfiguration{
-					MinLimit:       twoCore,
-					MaxLimit:       twoCore,
-					DefaultLimit:   twoCore,
-					DefaultRequest: oneCore,
+					MinLimit:       &twoCore,
+					MaxLimit:       &twoCore,
+					DefaultLimit:   &twoCore,
+					DefaultRequest: &oneCore,
 				},
 				IgnoreImages: []string{},
 			},
@@ -600,7 +603,7 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 			Settings{
 				Cpu: &ResourceConfiguration{
-					MaxRequest: oneCore,
+					MaxRequest: &oneCore,
 				},
 			},
 			&corev1.ResourceRequirements{
@@ -623,7 +626,7 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 			Settings{
 				Memory: &ResourceConfiguration{
-					MaxRequest: oneGi,
+					MaxRequest: &oneGi,
 				},
 			},
 			&corev1.ResourceRequirements{
@@ -646,7 +649,7 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 			Settings{
 				Cpu: &ResourceConfiguration{
-					MinLimit: twoCore,
+					MinLimit: &twoCore,
 				},
 			},
 			&corev1.ResourceRequirements{
@@ -672,8 +675,8 @@ func TestContainerIsRequiredToHaveLimits(t *testing.T) {
 			},
 			Settings{
 				Cpu: &ResourceConfiguration{
-					MaxRequest: resource.MustParse("6"),
-					MinLimit:   resource.MustParse("6"),
+					MaxRequest: quantityPtr("6"),
+					MinLimit:   quantityPtr("6"),
 				},
 			},
 			&corev1.ResourceRequirements{
@@ -746,9 +749,9 @@ func TestIgnoreValues(t *testing.T) {
 					IgnoreValues: true,
 				},
 				Memory: &ResourceConfiguration{
-					DefaultLimit:   oneGi,
-					DefaultRequest: oneGi,
-					MaxLimit:       oneGi,
+					DefaultLimit:   &oneGi,
+					DefaultRequest: &oneGi,
+					MaxLimit:       &oneGi,
 				},
 				IgnoreImages: []string{"image1:latest"},
 			},
@@ -777,9 +780,9 @@ func TestIgnoreValues(t *testing.T) {
 			},
 		}, Settings{
 			Cpu: &ResourceConfiguration{
-				DefaultLimit:   oneCore,
-				DefaultRequest: oneCore,
-				MaxLimit:       oneCore,
+				DefaultLimit:   &oneCore,
+				DefaultRequest: &oneCore,
+				MaxLimit:       &oneCore,
 				IgnoreValues:   true,
 			},
 			Memory: &ResourceConfiguration{
@@ -824,9 +827,9 @@ func TestIgnoreValues(t *testing.T) {
 				IgnoreValues: true,
 			},
 			Memory: &ResourceConfiguration{
-				DefaultLimit:   oneGi,
-				DefaultRequest: oneGi,
-				MaxLimit:       oneGi,
+				DefaultLimit:   &oneGi,
+				DefaultRequest: &oneGi,
+				MaxLimit:       &oneGi,
 			},
 		}, &corev1.ResourceRequirements{
 			Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
@@ -848,9 +851,9 @@ func TestIgnoreValues(t *testing.T) {
 			},
 		}, Settings{
 			Cpu: &ResourceConfiguration{
-				DefaultLimit:   oneCore,
-				DefaultRequest: oneCore,
-				MaxLimit:       oneCore,
+				DefaultLimit:   &oneCore,
+				DefaultRequest: &oneCore,
+				MaxLimit:       &oneCore,
 			},
 			Memory: &ResourceConfiguration{
 				IgnoreValues: true,
@@ -876,9 +879,9 @@ func TestIgnoreValues(t *testing.T) {
 			},
 		}, Settings{
 			Cpu: &ResourceConfiguration{
-				DefaultLimit:   oneCore,
-				DefaultRequest: oneCore,
-				MaxLimit:       oneCore,
+				DefaultLimit:   &oneCore,
+				DefaultRequest: &oneCore,
+				MaxLimit:       &oneCore,
 			},
 			Memory: &ResourceConfiguration{
 				IgnoreValues: true,
@@ -905,9 +908,9 @@ func TestIgnoreValues(t *testing.T) {
 			},
 		}, Settings{
 			Cpu: &ResourceConfiguration{
-				DefaultLimit:   oneCore,
-				DefaultRequest: oneCore,
-				MaxLimit:       oneCore,
+				DefaultLimit:   &oneCore,
+				DefaultRequest: &oneCore,
+				MaxLimit:       &oneCore,
 			},
 			Memory: nil,
 		}, &corev1.ResourceRequirements{
@@ -932,9 +935,9 @@ func TestIgnoreValues(t *testing.T) {
 		}, Settings{
 			Cpu: nil,
 			Memory: &ResourceConfiguration{
-				DefaultLimit:   oneGi,
-				DefaultRequest: oneGi,
-				MaxLimit:       oneGi,
+				DefaultLimit:   &oneGi,
+				DefaultRequest: &oneGi,
+				MaxLimit:       &oneGi,
 			},
 		}, &corev1.ResourceRequirements{
 			Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
@@ -991,19 +994,19 @@ func TestIgnoreImageSettings(t *testing.T) {
 	}
 	settings := Settings{
 		Cpu: &ResourceConfiguration{
-			DefaultLimit:   oneCore,
-			DefaultRequest: oneCore,
+			DefaultLimit:   &oneCore,
+			DefaultRequest: &oneCore,
 		},
 		Memory: &ResourceConfiguration{
-			DefaultLimit:   oneGi,
-			DefaultRequest: oneGi,
+			DefaultLimit:   &oneGi,
+			DefaultRequest: &oneGi,
 		},
 		IgnoreImages: []string{"image1:latest"},
 	}
 	podSpec := &corev1.PodSpec{
 		Containers: []*corev1.Container{&container1, &container2, &container3},
 	}
-	mutate, err := validatePodSpec(podSpec, &settings)
+	mutate, err := validatePodSpec(podSpec, nil, &settings)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1080,19 +1083,19 @@ func TestIgnoreImageWithNoTags(t *testing.T) {
 	}
 	settings := Settings{
 		Cpu: &ResourceConfiguration{
-			DefaultLimit:   oneCore,
-			DefaultRequest: oneCore,
+			DefaultLimit:   &oneCore,
+			DefaultRequest: &oneCore,
 		},
 		Memory: &ResourceConfiguration{
-			DefaultLimit:   oneGi,
-			DefaultRequest: oneGi,
+			DefaultLimit:   &oneGi,
+			DefaultRequest: &oneGi,
 		},
 		IgnoreImages: []string{"othersimage:*"},
 	}
 	podSpec := &corev1.PodSpec{
 		Containers: []*corev1.Container{&container1, &container2, &container3},
 	}
-	mutate, err := validatePodSpec(podSpec, &settings)
+	mutate, err := validatePodSpec(podSpec, nil, &settings)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1175,3 +1178,2041 @@ func TestImageComparison(t *testing.T) {
 		})
 	}
 }
+
+func TestWithIndefiniteArticle(t *testing.T) {
+	tests := []struct {
+		resourceName string
+		expected     string
+	}{
+		{"memory", "a memory"},
+		{"ephemeral-storage", "an ephemeral-storage"},
+		{"", "a "},
+	}
+	for _, test := range tests {
+		if result := withIndefiniteArticle(test.resourceName); result != test.expected {
+			t.Errorf("withIndefiniteArticle returned %q, expected %q", result, test.expected)
+		}
+	}
+}
+
+func TestInitContainers(t *testing.T) {
+	oneCore := resource.MustParse("1")
+	twoCore := resource.MustParse("2")
+	oneGi := resource.MustParse("1Gi")
+	oneCoreCpuQuantity := apimachinery_pkg_api_resource.Quantity("1")
+	twoCoreCpuQuantity := apimachinery_pkg_api_resource.Quantity("2")
+	oneGiMemoryQuantity := apimachinery_pkg_api_resource.Quantity("1Gi")
+
+	tests := []struct {
+		name                   string
+		podSpec                corev1.PodSpec
+		settings               Settings
+		expectedInitContainers []*corev1.Container
+		shouldMutate           bool
+		expectedErrorMsg       string
+	}{
+		{
+			"init container without resources gets the defaults",
+			corev1.PodSpec{
+				InitContainers: []*corev1.Container{{Image: "init:latest"}},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultRequest: &oneCore,
+					DefaultLimit:   &oneCore,
+				},
+				Memory: &ResourceConfiguration{
+					DefaultRequest: &oneGi,
+					DefaultLimit:   &oneGi,
+				},
+			},
+			[]*corev1.Container{
+				{
+					Image: "init:latest",
+					Resources: &corev1.ResourceRequirements{
+						Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"cpu":    &oneCoreCpuQuantity,
+							"memory": &oneGiMemoryQuantity,
+						},
+						Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"cpu":    &oneCoreCpuQuantity,
+							"memory": &oneGiMemoryQuantity,
+						},
+					},
+				},
+			}, true, "",
+		},
+		{
+			"init container exceeding the max limit",
+			corev1.PodSpec{
+				InitContainers: []*corev1.Container{
+					{
+						Image: "init:latest",
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"cpu": &twoCoreCpuQuantity,
+							},
+						},
+					},
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxLimit: &oneCore,
+				},
+			},
+			nil, false, "invalid init container\ncpu limit '2' exceeds the max allowed value '1'",
+		},
+		{
+			"init container validated using the initContainers settings",
+			corev1.PodSpec{
+				InitContainers: []*corev1.Container{
+					{
+						Image: "init:latest",
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"cpu": &twoCoreCpuQuantity,
+							},
+						},
+					},
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxLimit: &oneCore,
+				},
+				InitContainers: &Settings{
+					Cpu: &ResourceConfiguration{
+						MaxLimit:       &twoCore,
+						DefaultRequest: &oneCore,
+					},
+				},
+			},
+			[]*corev1.Container{
+				{
+					Image: "init:latest",
+					Resources: &corev1.ResourceRequirements{
+						Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"cpu": &twoCoreCpuQuantity,
+						},
+						Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"cpu": &oneCoreCpuQuantity,
+						},
+					},
+				},
+			}, true, "",
+		},
+		{
+			"init container missing limits when ignoreValues is true",
+			corev1.PodSpec{
+				InitContainers: []*corev1.Container{{Image: "init:latest"}},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					IgnoreValues: true,
+				},
+			},
+			nil, false, "invalid init container\ncontainer does not have any resource limits or requests",
+		},
+		{
+			"init container using an ignored image",
+			corev1.PodSpec{
+				InitContainers: []*corev1.Container{{Image: "init:latest"}},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					IgnoreValues: true,
+				},
+				IgnoreImages: []string{"init:*"},
+				InitContainers: &Settings{
+					Cpu: &ResourceConfiguration{
+						DefaultLimit: &oneCore,
+					},
+				},
+			},
+			[]*corev1.Container{{Image: "init:latest"}}, false, "",
+		},
+		{
+			"sidecar container validated using the app containers settings",
+			corev1.PodSpec{
+				InitContainers: []*corev1.Container{
+					{
+						Image:         "sidecar:latest",
+						RestartPolicy: "Always",
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"cpu": &twoCoreCpuQuantity,
+							},
+						},
+					},
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxLimit: &oneCore,
+				},
+				InitContainers: &Settings{
+					Cpu: &ResourceConfiguration{
+						MaxLimit: &twoCore,
+					},
+				},
+			},
+			nil, false, "invalid sidecar container\ncpu limit '2' exceeds the max allowed value '1'",
+		},
+		{
+			"sidecar container validated using the sidecar settings",
+			corev1.PodSpec{
+				InitContainers: []*corev1.Container{
+					{
+						Image:         "sidecar:latest",
+						RestartPolicy: "Always",
+					},
+					{
+						Image: "init:latest",
+					},
+				},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultLimit: &twoCore,
+				},
+				Sidecar: &Settings{
+					Cpu: &ResourceConfiguration{
+						DefaultLimit: &oneCore,
+					},
+				},
+			},
+			[]*corev1.Container{
+				{
+					Image:         "sidecar:latest",
+					RestartPolicy: "Always",
+					Resources: &corev1.ResourceRequirements{
+						Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"cpu": &oneCoreCpuQuantity,
+						},
+						Requests: map[string]*apimachinery_pkg_api_resource.Quantity{},
+					},
+				},
+				{
+					Image: "init:latest",
+					Resources: &corev1.ResourceRequirements{
+						Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"cpu": &twoCoreCpuQuantity,
+						},
+						Requests: map[string]*apimachinery_pkg_api_resource.Quantity{},
+					},
+				},
+			}, true, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mutated, err := validatePodSpec(&test.podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedInitContainers, test.podSpec.InitContainers); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestEphemeralContainers(t *testing.T) {
+	oneGi := resource.MustParse("1Gi")
+	twoGiMemoryQuantity := apimachinery_pkg_api_resource.Quantity("2Gi")
+	existingContainerName := "debugger-old"
+	newContainerName := "debugger"
+
+	tests := []struct {
+		name                        string
+		podSpec                     corev1.PodSpec
+		oldPodSpec                  *corev1.PodSpec
+		settings                    Settings
+		expectedEphemeralContainers []*corev1.EphemeralContainer
+		shouldMutate                bool
+		expectedErrorMsg            string
+	}{
+		{
+			"new ephemeral container without resources is accepted",
+			corev1.PodSpec{
+				EphemeralContainers: []*corev1.EphemeralContainer{{Name: &newContainerName, Image: "busybox"}},
+			},
+			nil,
+			Settings{
+				Memory: &ResourceConfiguration{
+					MaxLimit: &oneGi,
+				},
+			},
+			[]*corev1.EphemeralContainer{{Name: &newContainerName, Image: "busybox"}}, false, "",
+		},
+		{
+			"new ephemeral container exceeding the max limit",
+			corev1.PodSpec{
+				EphemeralContainers: []*corev1.EphemeralContainer{
+					{
+						Name:  &newContainerName,
+						Image: "busybox",
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"memory": &twoGiMemoryQuantity,
+							},
+						},
+					},
+				},
+			},
+			nil,
+			Settings{
+				Memory: &ResourceConfiguration{
+					MaxLimit: &oneGi,
+				},
+			},
+			nil, false, "invalid ephemeral container\nmemory limit '2Gi' exceeds the max allowed value '1Gi'",
+		},
+		{
+			"existing ephemeral containers are not validated",
+			corev1.PodSpec{
+				EphemeralContainers: []*corev1.EphemeralContainer{{Name: &existingContainerName, Image: "busybox"}},
+			},
+			&corev1.PodSpec{
+				EphemeralContainers: []*corev1.EphemeralContainer{{Name: &existingContainerName, Image: "busybox"}},
+			},
+			Settings{
+				Memory: &ResourceConfiguration{
+					MaxLimit: &oneGi,
+				},
+			},
+			[]*corev1.EphemeralContainer{{Name: &existingContainerName, Image: "busybox"}}, false, "",
+		},
+		{
+			"new ephemeral container exceeding the max limit next to an existing one",
+			corev1.PodSpec{
+				EphemeralContainers: []*corev1.EphemeralContainer{
+					{Name: &existingContainerName, Image: "busybox"},
+					{
+						Name:  &newContainerName,
+						Image: "busybox",
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"memory": &twoGiMemoryQuantity,
+							},
+						},
+					},
+				},
+			},
+			&corev1.PodSpec{
+				EphemeralContainers: []*corev1.EphemeralContainer{{Name: &existingContainerName, Image: "busybox"}},
+			},
+			Settings{
+				Memory: &ResourceConfiguration{
+					MaxLimit: &oneGi,
+				},
+			},
+			nil, false, "invalid ephemeral container\nmemory limit '2Gi' exceeds the max allowed value '1Gi'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mutated, err := validateEphemeralContainers(&test.podSpec, test.oldPodSpec, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedEphemeralContainers, test.podSpec.EphemeralContainers); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestEphemeralContainersSubResourceRequest(t *testing.T) {
+	tests := []struct {
+		name             string
+		fixture          string
+		settings         string
+		shouldAccept     bool
+		shouldMutate     bool
+		expectedErrorMsg string
+	}{
+		{
+			"ephemeral containers are not validated by default",
+			"test_data/pod_ephemeral_containers_subresource.json",
+			`{"memory": {"ignoreValues": true}}`,
+			true, false, "",
+		},
+		{
+			"new ephemeral container without resources is accepted",
+			"test_data/pod_ephemeral_containers_subresource.json",
+			`{"memory": {"ignoreValues": true}, "ephemeralContainers": {"memory": {"maxLimit": "512Mi"}}}`,
+			true, false, "",
+		},
+		{
+			"new ephemeral container exceeding the max limit is rejected",
+			"test_data/pod_ephemeral_containers_subresource_with_resources.json",
+			`{"memory": {"ignoreValues": true}, "ephemeralContainers": {"memory": {"maxLimit": "512Mi"}}}`,
+			false, false, "invalid ephemeral container\nmemory limit '1Gi' exceeds the max allowed value '512Mi'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := kubewarden_testing.BuildValidationRequestFromFixture(
+				test.fixture,
+				json.RawMessage(test.settings))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			responsePayload, err := validate(payload)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var response kubewarden_protocol.ValidationResponse
+			if err := json.Unmarshal(responsePayload, &response); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.Accepted != test.shouldAccept {
+				t.Fatalf("unexpected validation result. Got accepted: %t, expected: %t", response.Accepted, test.shouldAccept)
+			}
+			if (response.MutatedObject != nil) != test.shouldMutate {
+				t.Fatalf("unexpected mutation. Got: %v", response.MutatedObject)
+			}
+			if len(test.expectedErrorMsg) > 0 && (response.Message == nil || !strings.Contains(*response.Message, test.expectedErrorMsg)) {
+				t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%v'", test.expectedErrorMsg, response.Message)
+			}
+		})
+	}
+}
+
+func TestEphemeralStorage(t *testing.T) {
+	oneGi := resource.MustParse("1Gi")
+	twoGi := resource.MustParse("2Gi")
+	oneGiQuantity := apimachinery_pkg_api_resource.Quantity("1Gi")
+	twoGiQuantity := apimachinery_pkg_api_resource.Quantity("2Gi")
+	threeGiQuantity := apimachinery_pkg_api_resource.Quantity("3Gi")
+	cacheVolume := "cache"
+	scratchVolume := "scratch"
+	tmpfsVolume := "tmpfs"
+	mountPath := "/mnt"
+
+	tests := []struct {
+		name               string
+		podSpec            corev1.PodSpec
+		settings           Settings
+		expectedContainers []*corev1.Container
+		shouldMutate       bool
+		expectedErrorMsg   string
+	}{
+		{
+			"ephemeral storage defaults injected",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest"}},
+			},
+			Settings{
+				EphemeralStorage: &ResourceConfiguration{
+					DefaultRequest: &oneGi,
+					DefaultLimit:   &twoGi,
+				},
+			},
+			[]*corev1.Container{
+				{
+					Image: "image:latest",
+					Resources: &corev1.ResourceRequirements{
+						Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"ephemeral-storage": &twoGiQuantity,
+						},
+						Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"ephemeral-storage": &oneGiQuantity,
+						},
+					},
+				},
+			}, true, "",
+		},
+		{
+			"ephemeral storage limit exceeding the max limit",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"ephemeral-storage": &threeGiQuantity,
+							},
+						},
+					},
+				},
+			},
+			Settings{
+				EphemeralStorage: &ResourceConfiguration{
+					MaxLimit: &twoGi,
+				},
+			},
+			nil, false, "ephemeral-storage limit '3Gi' exceeds the max allowed value '2Gi'",
+		},
+		{
+			"ephemeral storage limit required",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"ephemeral-storage": &oneGiQuantity,
+							},
+						},
+					},
+				},
+			},
+			Settings{
+				EphemeralStorage: &ResourceConfiguration{
+					IgnoreValues: true,
+				},
+			},
+			nil, false, "container does not have an ephemeral-storage limit",
+		},
+		{
+			"emptyDir volumes within the ephemeral storage limit",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"ephemeral-storage": &twoGiQuantity,
+							},
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{},
+						},
+						VolumeMounts: []*corev1.VolumeMount{
+							{Name: &cacheVolume, MountPath: &mountPath},
+							{Name: &tmpfsVolume, MountPath: &mountPath},
+						},
+					},
+				},
+				Volumes: []*corev1.Volume{
+					{Name: &cacheVolume, EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &twoGiQuantity}},
+					{Name: &tmpfsVolume, EmptyDir: &corev1.EmptyDirVolumeSource{Medium: "Memory", SizeLimit: &twoGiQuantity}},
+					{Name: &scratchVolume, EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &twoGiQuantity}},
+				},
+			},
+			Settings{
+				EphemeralStorage: &ResourceConfiguration{
+					MaxLimit: &twoGi,
+				},
+				CheckEmptyDirSizeLimit: true,
+			},
+			[]*corev1.Container{
+				{
+					Image: "image:latest",
+					Resources: &corev1.ResourceRequirements{
+						Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"ephemeral-storage": &twoGiQuantity,
+						},
+						Requests: map[string]*apimachinery_pkg_api_resource.Quantity{},
+					},
+					VolumeMounts: []*corev1.VolumeMount{
+						{Name: &cacheVolume, MountPath: &mountPath},
+						{Name: &tmpfsVolume, MountPath: &mountPath},
+					},
+				},
+			}, false, "",
+		},
+		{
+			"emptyDir volumes exceeding the ephemeral storage limit",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Name:  &cacheVolume,
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"ephemeral-storage": &twoGiQuantity,
+							},
+						},
+						VolumeMounts: []*corev1.VolumeMount{
+							{Name: &cacheVolume, MountPath: &mountPath},
+							{Name: &scratchVolume, MountPath: &mountPath},
+						},
+					},
+				},
+				Volumes: []*corev1.Volume{
+					{Name: &cacheVolume, EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &oneGiQuantity}},
+					{Name: &scratchVolume, EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &twoGiQuantity}},
+				},
+			},
+			Settings{
+				EphemeralStorage: &ResourceConfiguration{
+					MaxLimit: &twoGi,
+				},
+				CheckEmptyDirSizeLimit: true,
+			},
+			nil, false, "container 'cache' mounts emptyDir volumes with a total size limit of '3Gi', which exceeds its ephemeral-storage limit '2Gi'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mutated, err := validatePodSpec(&test.podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedContainers, test.podSpec.Containers); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestResourcesSettings(t *testing.T) {
+	twoQuantity := apimachinery_pkg_api_resource.Quantity("2")
+	fiveQuantity := apimachinery_pkg_api_resource.Quantity("5")
+	settings := Settings{
+		Resources: map[string]*ResourceConfiguration{
+			"ephemeral-storage": {
+				DefaultLimit:   quantityPtr("2"),
+				DefaultRequest: quantityPtr("2"),
+				MaxLimit:       quantityPtr("4"),
+			},
+			"example.com/foo": {
+				MaxLimit: quantityPtr("4"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name               string
+		podSpec            corev1.PodSpec
+		settings           Settings
+		expectedContainers []*corev1.Container
+		shouldMutate       bool
+		expectedErrorMsg   string
+	}{
+		{
+			"defaults injected",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest"}},
+			},
+			settings,
+			[]*corev1.Container{
+				{
+					Image: "image:latest",
+					Resources: &corev1.ResourceRequirements{
+						Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"ephemeral-storage": &twoQuantity,
+						},
+						Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+							"ephemeral-storage": &twoQuantity,
+						},
+					},
+				},
+			}, true, "",
+		},
+		{
+			"limit exceeding the max limit",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Limits: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"example.com/foo": &fiveQuantity,
+							},
+						},
+					},
+				},
+			},
+			settings, nil, false, "example.com/foo limit '5' exceeds the max allowed value '4'",
+		},
+		{
+			"missing required resource",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest"}},
+			},
+			Settings{
+				Resources: map[string]*ResourceConfiguration{
+					"ephemeral-storage": {IgnoreValues: true},
+				},
+			},
+			nil, false, "container does not have any resource limits or requests: required ephemeral-storage",
+		},
+		{
+			"missing required resource limit",
+			corev1.PodSpec{
+				Containers: []*corev1.Container{
+					{
+						Image: "image:latest",
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*apimachinery_pkg_api_resource.Quantity{
+								"ephemeral-storage": &twoQuantity,
+							},
+						},
+					},
+				},
+			},
+			Settings{
+				Resources: map[string]*ResourceConfiguration{
+					"ephemeral-storage": {IgnoreValues: true},
+				},
+			},
+			nil, false, "container does not have an ephemeral-storage limit",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mutated, err := validatePodSpec(&test.podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedContainers, test.podSpec.Containers); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestLimitRequestRatio(t *testing.T) {
+	oneGi := apimachinery_pkg_api_resource.Quantity("1Gi")
+	oneAndHalfGi := apimachinery_pkg_api_resource.Quantity("1536Mi")
+	twoGi := apimachinery_pkg_api_resource.Quantity("2Gi")
+	zero := apimachinery_pkg_api_resource.Quantity("0")
+	ratioSettings := Settings{
+		Memory: &ResourceConfiguration{
+			MaxLimitRequestRatio: quantityPtr("1.5"),
+		},
+	}
+	deltaSettings := Settings{
+		Memory: &ResourceConfiguration{
+			MaxLimitRequestDelta: quantityPtr("512Mi"),
+		},
+	}
+
+	tests := []struct {
+		name             string
+		resources        *corev1.ResourceRequirements
+		settings         Settings
+		expectedErrorMsg string
+	}{
+		{
+			"limit within the max ratio",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneAndHalfGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			ratioSettings, "",
+		},
+		{
+			"limit exceeding the max ratio",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &twoGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			ratioSettings, "invalid memory limit to request ratio\nlimit '2Gi' is more than '1500m' times the request '1Gi'",
+		},
+		{
+			"limit without a request",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &twoGi},
+			},
+			ratioSettings, "",
+		},
+		{
+			"request without a limit",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			ratioSettings, "container does not have a memory limit. The limit is required to bound the memory limit to request ratio",
+		},
+		{
+			"zero request",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &zero},
+			},
+			ratioSettings, "the limit to request ratio is unbounded, because the request is zero",
+		},
+		{
+			"limit exceeding the ratio of the default request",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &twoGi},
+			},
+			Settings{
+				Memory: &ResourceConfiguration{
+					DefaultRequest:       quantityPtr("1Gi"),
+					MaxLimitRequestRatio: quantityPtr("1.5"),
+				},
+			},
+			"limit '2Gi' is more than '1500m' times the request '1Gi'",
+		},
+		{
+			"limit within the max delta",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneAndHalfGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			deltaSettings, "",
+		},
+		{
+			"limit exceeding the max delta",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &twoGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			deltaSettings, "limit '2Gi' exceeds the request '1Gi' by '1Gi', more than the max allowed delta '512Mi'",
+		},
+		{
+			"limit exceeding the request when the max delta is zero",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &twoGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			Settings{
+				Memory: &ResourceConfiguration{
+					MaxLimitRequestDelta: quantityPtr("0"),
+				},
+			},
+			"limit '2Gi' exceeds the request '1Gi' by '1Gi', more than the max allowed delta '0'",
+		},
+		{
+			"limit equal to the request when the max delta is zero",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			Settings{
+				Memory: &ResourceConfiguration{
+					MaxLimitRequestDelta: quantityPtr("0"),
+				},
+			},
+			"",
+		},
+		{
+			"container not using the resource",
+			&corev1.ResourceRequirements{},
+			deltaSettings, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest", Resources: test.resources}},
+			}
+			_, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+		})
+	}
+}
+
+func TestRequestEqualsLimit(t *testing.T) {
+	oneGi := apimachinery_pkg_api_resource.Quantity("1Gi")
+	twoGi := apimachinery_pkg_api_resource.Quantity("2Gi")
+	requireSettings := Settings{
+		Memory: &ResourceConfiguration{
+			DefaultLimit:              quantityPtr("2Gi"),
+			RequireRequestEqualsLimit: true,
+		},
+	}
+	mirrorSettings := Settings{
+		Memory: &ResourceConfiguration{
+			DefaultLimit:              quantityPtr("2Gi"),
+			DefaultRequest:            quantityPtr("2Gi"),
+			MaxLimit:                  quantityPtr("2Gi"),
+			RequireRequestEqualsLimit: true,
+			MirrorRequestAndLimit:     true,
+		},
+	}
+
+	tests := []struct {
+		name              string
+		resources         *corev1.ResourceRequirements
+		settings          Settings
+		expectedResources *corev1.ResourceRequirements
+		shouldMutate      bool
+		expectedErrorMsg  string
+	}{
+		{
+			"request equal to the limit",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			requireSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			false, "",
+		},
+		{
+			"request different from the limit",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &twoGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			requireSettings, nil, false, "memory request '1Gi' is not equal to the limit '2Gi'",
+		},
+		{
+			"request different from the default limit",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			requireSettings, nil, false, "memory request '1Gi' is not equal to the limit '2Gi'",
+		},
+		{
+			"limit copied from the request",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			mirrorSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			true, "",
+		},
+		{
+			"request copied from the limit",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			mirrorSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+			},
+			true, "",
+		},
+		{
+			"defaults applied when both are missing",
+			nil,
+			mirrorSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &twoGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &twoGi},
+			},
+			true, "",
+		},
+		{
+			"copied limit validated against the max limit",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &twoGi},
+			},
+			Settings{
+				Memory: &ResourceConfiguration{
+					MaxLimit:                  quantityPtr("1Gi"),
+					RequireRequestEqualsLimit: true,
+					MirrorRequestAndLimit:     true,
+				},
+			},
+			nil, false, "memory limit '2Gi' exceeds the max allowed value '1Gi'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest", Resources: test.resources}},
+			}
+			mutated, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedResources, podSpec.Containers[0].Resources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestLimitPolicy(t *testing.T) {
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	halfCore := apimachinery_pkg_api_resource.Quantity("500m")
+	defaultRequest := apimachinery_pkg_api_resource.Quantity("100m")
+	forbiddenSettings := Settings{
+		Cpu: &ResourceConfiguration{
+			DefaultRequest: quantityPtr("100m"),
+			LimitPolicy:    limitPolicyForbidden,
+		},
+	}
+	removeSettings := Settings{
+		Cpu: &ResourceConfiguration{
+			DefaultRequest:       quantityPtr("100m"),
+			MaxRequest:           quantityPtr("2"),
+			LimitPolicy:          limitPolicyForbidden,
+			ForbiddenLimitAction: forbiddenLimitActionRemove,
+		},
+	}
+
+	tests := []struct {
+		name              string
+		resources         *corev1.ResourceRequirements
+		settings          Settings
+		expectedResources *corev1.ResourceRequirements
+		shouldMutate      bool
+		expectedErrorMsg  string
+	}{
+		{
+			"forbidden limit rejected",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			forbiddenSettings, nil, false, "container has a cpu limit, but the cpu limits are forbidden",
+		},
+		{
+			"forbidden limit removed",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			removeSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			true, "",
+		},
+		{
+			"request defaulted after removing the forbidden limit",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			removeSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultRequest},
+			},
+			true, "",
+		},
+		{
+			"request required with forbidden limits",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					IgnoreValues: true,
+					LimitPolicy:  limitPolicyForbidden,
+				},
+			},
+			nil, false, "container does not have a cpu request",
+		},
+		{
+			"required limit missing",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxRequest:  quantityPtr("1"),
+					LimitPolicy: limitPolicyRequired,
+				},
+			},
+			nil, false, "container does not have a cpu limit, which is required",
+		},
+		{
+			"required limit injected",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultLimit: quantityPtr("1"),
+					LimitPolicy:  limitPolicyRequired,
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			true, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest", Resources: test.resources}},
+			}
+			mutated, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedResources, podSpec.Containers[0].Resources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestStep(t *testing.T) {
+	cpuRequest := apimachinery_pkg_api_resource.Quantity("120m")
+	cpuLimit := apimachinery_pkg_api_resource.Quantity("1")
+	roundedCpuRequest := apimachinery_pkg_api_resource.Quantity("150m")
+	memoryLimit := apimachinery_pkg_api_resource.Quantity("130Mi")
+	roundedMemoryLimit := apimachinery_pkg_api_resource.Quantity("192Mi")
+	rejectSettings := Settings{
+		Cpu: &ResourceConfiguration{
+			MaxLimit: quantityPtr("2"),
+			Step:     quantityPtr("50m"),
+		},
+	}
+	roundUpSettings := Settings{
+		Cpu: &ResourceConfiguration{
+			MaxRequest: quantityPtr("1"),
+			Step:       quantityPtr("50m"),
+			StepMode:   stepModeRoundUp,
+		},
+		Memory: &ResourceConfiguration{
+			MaxLimit: quantityPtr("1Gi"),
+			Step:     quantityPtr("64Mi"),
+			StepMode: stepModeRoundUp,
+		},
+	}
+
+	tests := []struct {
+		name              string
+		resources         *corev1.ResourceRequirements
+		settings          Settings
+		expectedResources *corev1.ResourceRequirements
+		shouldMutate      bool
+		expectedErrorMsg  string
+	}{
+		{
+			"values multiple of the step",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &cpuLimit},
+			},
+			rejectSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &cpuLimit},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{},
+			},
+			false, "",
+		},
+		{
+			"request not multiple of the step rejected",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &cpuLimit},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &cpuRequest},
+			},
+			rejectSettings, nil, false, "cpu request '120m' is not a multiple of the step '50m'",
+		},
+		{
+			"values rounded up to the step",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &memoryLimit},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &cpuRequest},
+			},
+			roundUpSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &roundedMemoryLimit},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &roundedCpuRequest},
+			},
+			true, "",
+		},
+		{
+			"rounded up value validated against the max value",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &cpuRequest},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxRequest: quantityPtr("100m"),
+					Step:       quantityPtr("100m"),
+					StepMode:   stepModeRoundUp,
+				},
+			},
+			nil, false, "cpu request '200m' exceeds the max allowed value '100m'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest", Resources: test.resources}},
+			}
+			mutated, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedResources, podSpec.Containers[0].Resources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestExplicitZeroValues(t *testing.T) {
+	oneGi := apimachinery_pkg_api_resource.Quantity("1Gi")
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	zero := apimachinery_pkg_api_resource.Quantity("0")
+	forbiddenSettings := Settings{
+		EphemeralStorage: &ResourceConfiguration{
+			MaxLimit:   quantityPtr("0"),
+			MaxRequest: quantityPtr("0"),
+		},
+	}
+
+	tests := []struct {
+		name              string
+		resources         *corev1.ResourceRequirements
+		settings          Settings
+		expectedResources *corev1.ResourceRequirements
+		shouldMutate      bool
+		expectedErrorMsg  string
+	}{
+		{
+			"forbidden resource limit",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"ephemeral-storage": &oneGi},
+			},
+			forbiddenSettings, nil, false, "ephemeral-storage limit '1Gi' exceeds the max allowed value '0'",
+		},
+		{
+			"forbidden resource request",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"ephemeral-storage": &oneGi},
+			},
+			forbiddenSettings, nil, false, "ephemeral-storage request '1Gi' exceeds the max allowed value '0'",
+		},
+		{
+			"container not using the forbidden resource",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			forbiddenSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{},
+			},
+			false, "",
+		},
+		{
+			"explicit zero default request injected",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultRequest: quantityPtr("0"),
+					MaxLimit:       quantityPtr("2"),
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &zero},
+			},
+			true, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest", Resources: test.resources}},
+			}
+			mutated, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedResources, podSpec.Containers[0].Resources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestRejectZero(t *testing.T) {
+	zero := apimachinery_pkg_api_resource.Quantity("0")
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	defaultRequest := apimachinery_pkg_api_resource.Quantity("100m")
+	rejectZeroSettings := Settings{
+		Cpu: &ResourceConfiguration{
+			DefaultRequest: quantityPtr("100m"),
+			MaxLimit:       quantityPtr("2"),
+			RejectZero:     true,
+		},
+	}
+	presenceSettings := Settings{
+		Memory: &ResourceConfiguration{
+			IgnoreValues: true,
+			RejectZero:   true,
+		},
+	}
+
+	tests := []struct {
+		name              string
+		resources         *corev1.ResourceRequirements
+		settings          Settings
+		expectedResources *corev1.ResourceRequirements
+		shouldMutate      bool
+		expectedErrorMsg  string
+	}{
+		{
+			"zero request replaced by the default request",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &zero},
+			},
+			rejectZeroSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultRequest},
+			},
+			true, "",
+		},
+		{
+			"zero limit without default limit",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &zero},
+			},
+			rejectZeroSettings, nil, false, "container has a zero cpu limit, which is not allowed",
+		},
+		{
+			"zero values allowed without reject zero",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &zero},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &zero},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultRequest: quantityPtr("100m"),
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &zero},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &zero},
+			},
+			false, "",
+		},
+		{
+			"zero limit handled as missing by the presence checks",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &zero},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &zero},
+			},
+			presenceSettings, nil, false, "container does not have a memory limit",
+		},
+		{
+			"zero request handled as missing by the presence checks",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &zero},
+			},
+			presenceSettings, nil, false, "container does not have a memory request",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest", Resources: test.resources}},
+			}
+			mutated, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedResources, podSpec.Containers[0].Resources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefaultingMode(t *testing.T) {
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	smallLimit := apimachinery_pkg_api_resource.Quantity("50m")
+	defaultRequest := apimachinery_pkg_api_resource.Quantity("100m")
+	defaultLimit := apimachinery_pkg_api_resource.Quantity("500m")
+	cpuSettings := &ResourceConfiguration{
+		DefaultRequest: quantityPtr("100m"),
+		DefaultLimit:   quantityPtr("500m"),
+	}
+
+	tests := []struct {
+		name              string
+		resources         *corev1.ResourceRequirements
+		settings          Settings
+		expectedResources *corev1.ResourceRequirements
+		shouldMutate      bool
+		expectedErrorMsg  string
+	}{
+		{
+			"request copied from the limit",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			Settings{Cpu: cpuSettings, DefaultingMode: defaultingModeKubernetes},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			true, "",
+		},
+		{
+			"default request injected by the policy mode",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			Settings{Cpu: cpuSettings, DefaultingMode: defaultingModePolicy},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultRequest},
+			},
+			true, "",
+		},
+		{
+			"limit lower than the default request",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+			},
+			Settings{Cpu: cpuSettings, DefaultingMode: defaultingModeKubernetes},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+			},
+			true, "",
+		},
+		{
+			"limit lower than the default request in the policy mode",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+			},
+			Settings{Cpu: cpuSettings},
+			nil, false, "There is an issue after resource requests mutation",
+		},
+		{
+			"default values injected without limit",
+			&corev1.ResourceRequirements{},
+			Settings{Cpu: cpuSettings, DefaultingMode: defaultingModeKubernetes},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultLimit},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultRequest},
+			},
+			true, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest", Resources: test.resources}},
+			}
+			mutated, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedResources, podSpec.Containers[0].Resources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestClampOnViolation(t *testing.T) {
+	twoCores := apimachinery_pkg_api_resource.Quantity("2")
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	halfCore := apimachinery_pkg_api_resource.Quantity("500m")
+	smallLimit := apimachinery_pkg_api_resource.Quantity("100m")
+	clampSettings := Settings{
+		Cpu: &ResourceConfiguration{
+			MaxRequest:  quantityPtr("500m"),
+			MinLimit:    quantityPtr("1"),
+			MaxLimit:    quantityPtr("2"),
+			OnViolation: onViolationClamp,
+		},
+	}
+
+	tests := []struct {
+		name              string
+		resources         *corev1.ResourceRequirements
+		settings          Settings
+		expectedResources *corev1.ResourceRequirements
+		shouldMutate      bool
+		expectedErrorMsg  string
+	}{
+		{
+			"request lowered to the max request",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+			},
+			clampSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			true, "",
+		},
+		{
+			"limit raised to the min limit",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+			},
+			clampSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{},
+			},
+			true, "",
+		},
+		{
+			"values in range not mutated",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			clampSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			false, "",
+		},
+		{
+			"clamped limit lower than the request",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxLimit:    quantityPtr("100m"),
+					OnViolation: onViolationClamp,
+				},
+			},
+			nil, false, "cpu limit '100m' is less than the requested '500m' value",
+		},
+		{
+			"violation rejected by default",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxRequest: quantityPtr("1"),
+				},
+			},
+			nil, false, "cpu request '2' exceeds the max allowed value '1'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest", Resources: test.resources}},
+			}
+			mutated, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedResources, podSpec.Containers[0].Resources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestRepairStrategy(t *testing.T) {
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	threeCores := apimachinery_pkg_api_resource.Quantity("3")
+	defaultLimit := apimachinery_pkg_api_resource.Quantity("500m")
+	smallLimit := apimachinery_pkg_api_resource.Quantity("100m")
+	raiseLimitSettings := Settings{
+		Cpu: &ResourceConfiguration{
+			DefaultLimit:   quantityPtr("500m"),
+			MaxLimit:       quantityPtr("2"),
+			RepairStrategy: repairStrategyRaiseLimit,
+		},
+	}
+	lowerRequestSettings := Settings{
+		Cpu: &ResourceConfiguration{
+			DefaultRequest: quantityPtr("500m"),
+			RepairStrategy: repairStrategyLowerRequest,
+		},
+	}
+
+	tests := []struct {
+		name              string
+		resources         *corev1.ResourceRequirements
+		settings          Settings
+		expectedResources *corev1.ResourceRequirements
+		shouldMutate      bool
+		expectedErrorMsg  string
+	}{
+		{
+			"injected limit raised to the request",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			raiseLimitSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+			},
+			true, "",
+		},
+		{
+			"injected limit capped at the max limit",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &threeCores},
+			},
+			raiseLimitSettings, nil, false, "cpu limit '2' is less than the requested '3' value",
+		},
+		{
+			"injected request lowered to the limit",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+			},
+			lowerRequestSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+			},
+			true, "",
+		},
+		{
+			"lowered request below the min request",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MinRequest:     quantityPtr("200m"),
+					DefaultRequest: quantityPtr("500m"),
+					RepairStrategy: repairStrategyLowerRequest,
+				},
+			},
+			nil, false, "cpu request '100m' doesn't reach the min allowed value '200m'",
+		},
+		{
+			"user values not repaired",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultRequest: quantityPtr("500m"),
+					RepairStrategy: repairStrategyRaiseLimit,
+				},
+			},
+			nil, false, "There is an issue after resource requests mutation",
+		},
+		{
+			"no repair needed",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+			},
+			raiseLimitSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultLimit},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &smallLimit},
+			},
+			true, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest", Resources: test.resources}},
+			}
+			mutated, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedResources, podSpec.Containers[0].Resources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestRatioDefaults(t *testing.T) {
+	halfCore := apimachinery_pkg_api_resource.Quantity("500m")
+	oneCore := apimachinery_pkg_api_resource.Quantity("1")
+	fourCores := apimachinery_pkg_api_resource.Quantity("4")
+	twoCores := apimachinery_pkg_api_resource.Quantity("2")
+	defaultRequest := apimachinery_pkg_api_resource.Quantity("100m")
+	defaultLimit := apimachinery_pkg_api_resource.Quantity("200m")
+	oddRequest := apimachinery_pkg_api_resource.Quantity("333m")
+	roundedLimit := apimachinery_pkg_api_resource.Quantity("500m")
+	oneGi := apimachinery_pkg_api_resource.Quantity("1Gi")
+	halfGi := apimachinery_pkg_api_resource.Quantity("512Mi")
+	oddBytes := apimachinery_pkg_api_resource.Quantity("1001")
+	roundedBytes := apimachinery_pkg_api_resource.Quantity("501")
+	stepRequest := apimachinery_pkg_api_resource.Quantity("300m")
+	steppedBytes := apimachinery_pkg_api_resource.Quantity("320Mi")
+	ratioSettings := Settings{
+		Cpu: &ResourceConfiguration{
+			DefaultLimitFromRequestRatio: quantityPtr("2"),
+			DefaultRequestFromLimitRatio: quantityPtr("0.5"),
+		},
+		Memory: &ResourceConfiguration{
+			DefaultRequestFromLimitRatio: quantityPtr("0.5"),
+		},
+	}
+
+	tests := []struct {
+		name              string
+		resources         *corev1.ResourceRequirements
+		settings          Settings
+		expectedResources *corev1.ResourceRequirements
+		shouldMutate      bool
+		expectedErrorMsg  string
+	}{
+		{
+			"limit derived from the request",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			ratioSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			true, "",
+		},
+		{
+			"request derived from the limit",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore, "memory": &oneGi},
+			},
+			ratioSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore, "memory": &oneGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore, "memory": &halfGi},
+			},
+			true, "",
+		},
+		{
+			"derived values rounded up",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oddBytes},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oddRequest},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultLimitFromRequestRatio: quantityPtr("1.5"),
+				},
+				Memory: &ResourceConfiguration{
+					DefaultRequestFromLimitRatio: quantityPtr("0.5"),
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &roundedLimit, "memory": &oddBytes},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oddRequest, "memory": &roundedBytes},
+			},
+			true, "",
+		},
+		{
+			"derived values rounded up to the step",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"memory": &oneGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &stepRequest},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultLimitFromRequestRatio: quantityPtr("1.5"),
+					Step:                         quantityPtr("100m"),
+				},
+				Memory: &ResourceConfiguration{
+					DefaultRequestFromLimitRatio: quantityPtr("0.3"),
+					Step:                         quantityPtr("64Mi"),
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &roundedLimit, "memory": &oneGi},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &stepRequest, "memory": &steppedBytes},
+			},
+			true, "",
+		},
+		{
+			"fixed defaults applied when both are missing",
+			&corev1.ResourceRequirements{},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultRequest:               quantityPtr("100m"),
+					DefaultLimit:                 quantityPtr("200m"),
+					DefaultLimitFromRequestRatio: quantityPtr("4"),
+					DefaultRequestFromLimitRatio: quantityPtr("0.25"),
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultLimit},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultRequest},
+			},
+			true, "",
+		},
+		{
+			"limit derived from the default request",
+			&corev1.ResourceRequirements{},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultRequest:               quantityPtr("100m"),
+					DefaultLimitFromRequestRatio: quantityPtr("2"),
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultLimit},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultRequest},
+			},
+			true, "",
+		},
+		{
+			"request derived from the default limit",
+			&corev1.ResourceRequirements{},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					DefaultLimit:                 quantityPtr("1"),
+					DefaultRequestFromLimitRatio: quantityPtr("0.5"),
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &oneCore},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			true, "",
+		},
+		{
+			"derived limit exceeding the max limit",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxLimit:                     quantityPtr("2"),
+					DefaultLimitFromRequestRatio: quantityPtr("2"),
+				},
+			},
+			nil, false, "cpu limit '4' exceeds the max allowed value '2'",
+		},
+		{
+			"derived limit clamped to the max limit",
+			&corev1.ResourceRequirements{
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MaxLimit:                     quantityPtr("4"),
+					DefaultLimitFromRequestRatio: quantityPtr("3"),
+					OnViolation:                  onViolationClamp,
+				},
+			},
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &fourCores},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+			},
+			true, "",
+		},
+		{
+			"derived request below the min request",
+			&corev1.ResourceRequirements{
+				Limits: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &defaultLimit},
+			},
+			Settings{
+				Cpu: &ResourceConfiguration{
+					MinRequest:                   quantityPtr("150m"),
+					DefaultRequestFromLimitRatio: quantityPtr("0.5"),
+				},
+			},
+			nil, false, "cpu request '100m' doesn't reach the min allowed value '150m'",
+		},
+		{
+			"container defining both values not mutated",
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			ratioSettings,
+			&corev1.ResourceRequirements{
+				Limits:   map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &twoCores},
+				Requests: map[string]*apimachinery_pkg_api_resource.Quantity{"cpu": &halfCore},
+			},
+			false, "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				Containers: []*corev1.Container{{Image: "image:latest", Resources: test.resources}},
+			}
+			mutated, err := validatePodSpec(&podSpec, nil, &test.settings)
+			if len(test.expectedErrorMsg) > 0 {
+				if err == nil {
+					t.Fatalf("expected error message with string '%s'. But no error has been returned", test.expectedErrorMsg)
+				}
+				if !strings.Contains(err.Error(), test.expectedErrorMsg) {
+					t.Fatalf("invalid error message. Expected the string '%s' in the error. Got '%s'", test.expectedErrorMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %q", err)
+			}
+			if mutated != test.shouldMutate {
+				t.Fatalf("validation function does not report mutation flag correctly. Got: %t, expected: %t", mutated, test.shouldMutate)
+			}
+			if diff := cmp.Diff(test.expectedResources, podSpec.Containers[0].Resources); diff != "" {
+				t.Fatalf("%s", diff)
+			}
+		})
+	}
+}